@@ -5,40 +5,74 @@ package admin
 
 import (
 	"context"
+	"fmt"
 
-	adminapi "github.com/MetalBlockchain/metalgo/api/admin"
+	"github.com/MetalBlockchain/metalgo/ids"
 )
 
 var _ Client = &client{}
 
 type Client interface {
-	LoadVMs() error
-	WhitelistSubnet(subnetID string) error
+	LoadVMs() (LoadVMsResult, error)
+}
+
+// LoadVMsResult is what the node loaded when asked to load new vms.
+type LoadVMsResult struct {
+	// NewVMs are the ids of the vms that were loaded, and their aliases.
+	NewVMs map[ids.ID][]string
+	// FailedVMs are the ids of the vms that failed to load, and the node's
+	// error.
+	FailedVMs map[ids.ID]string
 }
 
 type client struct {
-	client adminapi.Client
+	requester *requester
 }
 
-func NewClient(url string) Client {
-	return &client{
-		client: adminapi.NewClient(url),
+// NewClient returns a client for the admin api at [url], like
+// https://127.0.0.1:9650/ext/admin. If [url] has no scheme, http is used.
+func NewClient(url string, options Options) (Client, error) {
+	requester, err := newRequester(url, options)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (c *client) LoadVMs() error {
-	_, _, err := c.client.LoadVMs(context.Background())
+	return &client{
+		requester: requester,
+	}, nil
+}
 
-	return err
+// loadVMsReply is the node's reply to admin.loadVMs. The ids are decoded as
+// strings since ids.ID can't unmarshal the unquoted map keys the node sends.
+type loadVMsReply struct {
+	NewVMs    map[string][]string `json:"newVMs"`
+	FailedVMs map[string]string   `json:"failedVMs,omitempty"`
 }
 
-func (c *client) WhitelistSubnet(subnetID string) error {
-	// id, err := ids.FromString(subnetID)
-	// if err != nil {
-	// 	return err
-	// }
-	//
-	// _, err = c.client.WhitelistSubnet(context.Background(), id)
-	// return err
-	return nil
+func (c *client) LoadVMs() (LoadVMsResult, error) {
+	reply := &loadVMsReply{}
+	if err := c.requester.sendRequest(context.Background(), "admin.loadVMs", struct{}{}, reply); err != nil {
+		return LoadVMsResult{}, err
+	}
+
+	result := LoadVMsResult{
+		NewVMs:    make(map[ids.ID][]string, len(reply.NewVMs)),
+		FailedVMs: make(map[ids.ID]string, len(reply.FailedVMs)),
+	}
+	for id, aliases := range reply.NewVMs {
+		vmID, err := ids.FromString(id)
+		if err != nil {
+			return LoadVMsResult{}, fmt.Errorf("invalid vm id %s: %w", id, err)
+		}
+		result.NewVMs[vmID] = aliases
+	}
+	for id, reason := range reply.FailedVMs {
+		vmID, err := ids.FromString(id)
+		if err != nil {
+			return LoadVMsResult{}, fmt.Errorf("invalid vm id %s: %w", id, err)
+		}
+		result.FailedVMs[vmID] = reason
+	}
+
+	return result, nil
 }
@@ -0,0 +1,111 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package admin
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientLoadVMs(t *testing.T) {
+	vmID := ids.ID{1}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		request := struct {
+			Method string `json:"method"`
+			ID     uint64 `json:"id"`
+		}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "admin.loadVMs", request.Method)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result": map[string]interface{}{
+				"newVMs": map[string][]string{vmID.String(): {"vm"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, ca, 0o600))
+
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{
+			name: "trusted ca",
+			options: Options{
+				CAFile:  caFile,
+				Headers: http.Header{"X-Api-Key": []string{"key"}},
+				Token:   "token",
+				Timeout: time.Minute,
+			},
+		},
+		{
+			name: "insecure",
+			options: Options{
+				InsecureSkipVerify: true,
+				Headers:            http.Header{"X-Api-Key": []string{"key"}},
+				Token:              "token",
+			},
+		},
+		{
+			name: "untrusted certificate",
+			options: Options{
+				Headers: http.Header{"X-Api-Key": []string{"key"}},
+				Token:   "token",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unauthorized",
+			options: Options{
+				CAFile: caFile,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := NewClient(server.URL+"/ext/admin", test.options)
+			assert.NoError(t, err)
+
+			result, err := client.LoadVMs()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, map[ids.ID][]string{vmID: {"vm"}}, result.NewVMs)
+		})
+	}
+}
+
+func TestNewClientInvalidOptions(t *testing.T) {
+	_, err := NewClient("https://node", Options{CertFile: "cert.pem"})
+	assert.ErrorIs(t, err, errIncompleteKeyPair)
+
+	_, err = NewClient("https://node", Options{CAFile: "missing.pem"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MetalBlockchain/metalgo/version"
+)
+
+const (
+	adminPath = "/ext/admin"
+	infoPath  = "/ext/info"
+
+	// nodeVersionTimeout keeps commands from hanging on a node that isn't
+	// up, since its version is only used for best-effort checks.
+	nodeVersionTimeout = 3 * time.Second
+)
+
+var _ InfoClient = &infoClient{}
+
+// InfoClient queries a node's info api.
+type InfoClient interface {
+	GetNodeVersion() (NodeVersion, error)
+}
+
+// NodeVersion is what a node reports about the software it runs.
+type NodeVersion struct {
+	Version *version.Semantic
+	// RPCChainVMProtocol is the rpcchainvm protocol version the node speaks
+	// to vm plugins. It's 0 if the node doesn't report it.
+	RPCChainVMProtocol uint
+}
+
+type infoClient struct {
+	requester *requester
+}
+
+// NewInfoClient returns a client for the info api at [url], like
+// https://127.0.0.1:9650/ext/info. If [url] has no scheme, http is used.
+func NewInfoClient(url string, options Options) (InfoClient, error) {
+	requester, err := newRequester(url, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &infoClient{
+		requester: requester,
+	}, nil
+}
+
+// InfoURL returns the url of the info api served by the same node as the
+// admin api at [adminURL].
+func InfoURL(adminURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(adminURL, "/"), adminPath) + infoPath
+}
+
+// getNodeVersionReply is the node's reply to info.getNodeVersion. Nodes that
+// report the rpcchainvm protocol send it as a number or a quoted number.
+type getNodeVersionReply struct {
+	Version            string          `json:"version"`
+	RPCProtocolVersion json.RawMessage `json:"rpcProtocolVersion,omitempty"`
+}
+
+func (c *infoClient) GetNodeVersion() (NodeVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeVersionTimeout)
+	defer cancel()
+
+	reply := &getNodeVersionReply{}
+	if err := c.requester.sendRequest(ctx, "info.getNodeVersion", struct{}{}, reply); err != nil {
+		return NodeVersion{}, err
+	}
+
+	application, err := version.ParseApplication(reply.Version)
+	if err != nil {
+		return NodeVersion{}, err
+	}
+	nodeVersion := NodeVersion{
+		Version: &version.Semantic{
+			Major: application.Major,
+			Minor: application.Minor,
+			Patch: application.Patch,
+		},
+	}
+
+	if len(reply.RPCProtocolVersion) > 0 {
+		protocol, err := strconv.ParseUint(strings.Trim(string(reply.RPCProtocolVersion), `"`), 10, 32)
+		if err != nil {
+			return NodeVersion{}, fmt.Errorf("invalid rpcchainvm protocol %s: %w", reply.RPCProtocolVersion, err)
+		}
+		nodeVersion.RPCChainVMProtocol = uint(protocol)
+	}
+
+	return nodeVersion, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfoClientGetNodeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		want    NodeVersion
+		wantErr bool
+	}{
+		{
+			name:   "without protocol",
+			result: `{"version": "metal/1.7.14"}`,
+			want: NodeVersion{
+				Version: &version.Semantic{Major: 1, Minor: 7, Patch: 14},
+			},
+		},
+		{
+			name:   "quoted protocol",
+			result: `{"version": "metal/1.7.14", "rpcProtocolVersion": "15"}`,
+			want: NodeVersion{
+				Version:            &version.Semantic{Major: 1, Minor: 7, Patch: 14},
+				RPCChainVMProtocol: 15,
+			},
+		},
+		{
+			name:   "numeric protocol",
+			result: `{"version": "metal/1.7.14", "rpcProtocolVersion": 15}`,
+			want: NodeVersion{
+				Version:            &version.Semantic{Major: 1, Minor: 7, Patch: 14},
+				RPCChainVMProtocol: 15,
+			},
+		},
+		{
+			name:    "invalid version",
+			result:  `{"version": "1.7.14"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := struct {
+					Method string `json:"method"`
+					ID     uint64 `json:"id"`
+				}{}
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+				assert.Equal(t, "info.getNodeVersion", request.Method)
+
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      request.ID,
+					"result":  json.RawMessage(test.result),
+				})
+			}))
+			defer server.Close()
+
+			client, err := NewInfoClient(server.URL+infoPath, Options{})
+			assert.NoError(t, err)
+
+			nodeVersion, err := client.GetNodeVersion()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want.RPCChainVMProtocol, nodeVersion.RPCChainVMProtocol)
+			assert.Equal(t, test.want.Version.String(), nodeVersion.Version.String())
+		})
+	}
+}
+
+func TestInfoURL(t *testing.T) {
+	assert.Equal(t, "https://node:9650/ext/info", InfoURL("https://node:9650/ext/admin"))
+	assert.Equal(t, "127.0.0.1:9650/ext/info", InfoURL("127.0.0.1:9650/ext/admin/"))
+	assert.Equal(t, "https://node:9650/ext/info", InfoURL("https://node:9650"))
+}
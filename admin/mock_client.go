@@ -37,11 +37,12 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // LoadVMs mocks base method.
-func (m *MockClient) LoadVMs() error {
+func (m *MockClient) LoadVMs() (LoadVMsResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "LoadVMs")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(LoadVMsResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // LoadVMs indicates an expected call of LoadVMs.
@@ -49,17 +50,3 @@ func (mr *MockClientMockRecorder) LoadVMs() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadVMs", reflect.TypeOf((*MockClient)(nil).LoadVMs))
 }
-
-// WhitelistSubnet mocks base method.
-func (m *MockClient) WhitelistSubnet(subnetID string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WhitelistSubnet", subnetID)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// WhitelistSubnet indicates an expected call of WhitelistSubnet.
-func (mr *MockClientMockRecorder) WhitelistSubnet(subnetID interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WhitelistSubnet", reflect.TypeOf((*MockClient)(nil).WhitelistSubnet), subnetID)
-}
@@ -0,0 +1,52 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Code generated by MockGen. DO NOT EDIT.
+// Source: admin/info.go
+
+// Package admin is a generated GoMock package.
+package admin
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInfoClient is a mock of InfoClient interface.
+type MockInfoClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockInfoClientMockRecorder
+}
+
+// MockInfoClientMockRecorder is the mock recorder for MockInfoClient.
+type MockInfoClientMockRecorder struct {
+	mock *MockInfoClient
+}
+
+// NewMockInfoClient creates a new mock instance.
+func NewMockInfoClient(ctrl *gomock.Controller) *MockInfoClient {
+	mock := &MockInfoClient{ctrl: ctrl}
+	mock.recorder = &MockInfoClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInfoClient) EXPECT() *MockInfoClientMockRecorder {
+	return m.recorder
+}
+
+// GetNodeVersion mocks base method.
+func (m *MockInfoClient) GetNodeVersion() (NodeVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeVersion")
+	ret0, _ := ret[0].(NodeVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodeVersion indicates an expected call of GetNodeVersion.
+func (mr *MockInfoClientMockRecorder) GetNodeVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeVersion", reflect.TypeOf((*MockInfoClient)(nil).GetNodeVersion))
+}
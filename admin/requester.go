@@ -0,0 +1,144 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package admin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+var (
+	errNoCertificates    = errors.New("no certificates found")
+	errIncompleteKeyPair = errors.New("a client certificate needs both a cert file and a key file")
+)
+
+// Options configures how a node's api is reached, besides its url.
+type Options struct {
+	// CAFile is a PEM bundle of the certificate authorities the node's
+	// certificate is verified with, instead of the system's.
+	CAFile string
+	// CertFile and KeyFile are a client certificate presented to the node.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify doesn't verify the node's certificate. Only use it
+	// for development.
+	InsecureSkipVerify bool
+	// Headers are added to every request.
+	Headers http.Header
+	// Token is sent as a bearer token. Basic auth credentials can be given
+	// in the url instead.
+	Token string
+	// Timeout limits each request. Zero means no limit.
+	Timeout time.Duration
+}
+
+// requester sends json-rpc requests to an api endpoint.
+type requester struct {
+	url     string
+	client  *http.Client
+	headers http.Header
+}
+
+func newRequester(url string, options Options) (*requester, error) {
+	// endpoints used to be configured without a scheme
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	tlsConfig, err := newTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	headers := options.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if options.Token != "" {
+		headers.Set("Authorization", "Bearer "+options.Token)
+	}
+
+	return &requester{
+		url: url,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   options.Timeout,
+		},
+		headers: headers,
+	}, nil
+}
+
+func newTLSConfig(options Options) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: options.InsecureSkipVerify, // #nosec G402 opt-in for development
+	}
+
+	if options.CAFile != "" {
+		pem, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w in %s", errNoCertificates, options.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if (options.CertFile == "") != (options.KeyFile == "") {
+		return nil, errIncompleteKeyPair
+	}
+	if options.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+func (r *requester) sendRequest(ctx context.Context, method string, params interface{}, reply interface{}) error {
+	body, err := json2.EncodeClientRequest(method, params)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header = r.headers.Clone()
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to issue request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("received status code: %d", response.StatusCode)
+	}
+
+	if err := json2.DecodeClientResponse(response.Body, reply); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -7,26 +7,27 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
-	"strings"
-	"syscall"
-	"text/tabwriter"
+	"time"
 
 	"github.com/MetalBlockchain/metalgo/database"
 	"github.com/MetalBlockchain/metalgo/database/leveldb"
-	"github.com/MetalBlockchain/metalgo/utils/logging"
+	metalgologging "github.com/MetalBlockchain/metalgo/utils/logging"
 	"github.com/MetalBlockchain/metalgo/utils/perms"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/afero"
 
 	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/config"
 	"github.com/shubhamdubey02/apm/constant"
 	"github.com/shubhamdubey02/apm/engine"
 	"github.com/shubhamdubey02/apm/git"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/metrics"
+	"github.com/shubhamdubey02/apm/node"
 	"github.com/shubhamdubey02/apm/storage"
-	"github.com/shubhamdubey02/apm/types"
 	"github.com/shubhamdubey02/apm/url"
 	"github.com/shubhamdubey02/apm/util"
 	"github.com/shubhamdubey02/apm/workflow"
@@ -36,101 +37,277 @@ var (
 	dbDir            = "db"
 	repositoryDir    = "repositories"
 	tmpDir           = "tmp"
-	metricsNamespace = "apm_db"
+	configBackupDir  = "config-backups"
+	metricsNamespace = "apm"
+	profileLabel     = "profile"
+	dbNamespace      = "apm_db"
 )
 
 type Config struct {
-	Directory        string
-	Auth             http.BasicAuth
+	Directory string
+	// Profile is the node whose installed vms and joined subnets are
+	// managed. Profiles share repositories and downloads, but have their own
+	// install records. It defaults to storage.DefaultProfile.
+	Profile string
+	// Credentials authenticate against the repositories' git remotes.
+	Credentials config.Credentials
+	// AdminAPIEndpoint is the url of the node's admin api. It may include
+	// basic auth credentials.
 	AdminAPIEndpoint string
-	PluginDir        string
-	Fs               afero.Fs
+	AdminAPIOptions  admin.Options
+	// InfoAPIEndpoint is the url of the node's info api, which vms are
+	// checked for compatibility against. It defaults to the info api next
+	// to the admin api. It's reached with AdminAPIOptions.
+	InfoAPIEndpoint string
+	PluginDir       string
+	// NodeConfigPath is the node's json config file, where joined subnets
+	// are tracked. It's optional.
+	NodeConfigPath string
+	// NodeConfigsDir is where the node reads subnet and chain configs from.
+	// It's optional.
+	NodeConfigsDir string
+	// CloneDepth is how many commits of each repository's history are
+	// fetched. Zero fetches the full history.
+	CloneDepth int
+	Fs         afero.Fs
 }
 
+// APM manages the plugins installed for a node.
+// Not safe for concurrent use.
 type APM struct {
 	db database.Database
 
-	sourcesList  storage.Storage[storage.SourceInfo]
-	installedVMs storage.Storage[storage.InstallInfo]
-	registry     storage.Storage[storage.RepoList]
-	repoFactory  storage.RepositoryFactory
+	sourcesList      storage.Storage[storage.SourceInfo]
+	installedVMs     storage.Storage[storage.InstallInfo]
+	registry         storage.Storage[storage.RepoList]
+	history          storage.Storage[storage.Operation]
+	autoUpgradeState storage.Storage[storage.AutoUpgradeState]
+	joinedSubnets    storage.Storage[storage.SubnetInfo]
+	repoFactory      storage.RepositoryFactory
 
 	executor workflow.Executor
 
-	auth http.BasicAuth
+	credentials config.Credentials
 
 	adminClient admin.Client
+	infoClient  admin.InfoClient
+	gitFactory  git.Factory
 	installer   workflow.Installer
 
+	// nodeVersion is asked for at most once per call, when a vm is first
+	// checked against it.
+	nodeVersion      *admin.NodeVersion
+	nodeVersionAsked bool
+
+	profile          string
 	repositoriesPath string
 	tmpPath          string
+	configBackupPath string
 	pluginPath       string
 	adminAPIEndpoint string
+	loadVMsOnInstall bool
+	force            bool
+	nodeConfigPath   string
+	nodeConfigsDir   string
 	fs               afero.Fs
+	log              logging.Logger
+	recorder         *recorder
+	metrics          *metrics.Metrics
+	closed           bool
 }
 
-func New(config Config) (*APM, error) {
+// New opens the apm's database in config.Directory. Unless WithoutBootstrap
+// is given, the core repository is added and synced if it isn't already.
+// The returned APM must be closed to release the database.
+func New(config Config, opts ...Option) (*APM, error) {
+	options := newOptions(opts)
+
+	adminClient := options.adminClient
+	if adminClient == nil {
+		var err error
+		adminClient, err = admin.NewClient(config.AdminAPIEndpoint, config.AdminAPIOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	infoClient := options.infoClient
+	if infoClient == nil {
+		infoAPIEndpoint := config.InfoAPIEndpoint
+		if infoAPIEndpoint == "" {
+			infoAPIEndpoint = admin.InfoURL(config.AdminAPIEndpoint)
+		}
+
+		var err error
+		infoClient, err = admin.NewInfoClient(infoAPIEndpoint, config.AdminAPIOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	dbDir := filepath.Join(config.Directory, dbDir)
-	db, err := leveldb.New(dbDir, []byte{}, logging.NoLog{}, metricsNamespace, prometheus.NewRegistry())
+	db, err := leveldb.New(dbDir, []byte{}, metalgologging.NoLog{}, dbNamespace, prometheus.NewRegistry())
 	if err != nil {
 		return nil, err
 	}
 
+	history := storage.NewHistory(db)
+	profile := config.Profile
+	if profile == storage.DefaultProfile {
+		profile = ""
+	}
+	profileDB := storage.NewProfile(db, profile)
+	recorder := &recorder{Logger: options.log}
+
+	gitFactory := options.gitFactory
+	if gitFactory == nil {
+		// only the definitions are read from repositories
+		gitFactory = git.NewRepositoryFactory(git.RepositoryFactoryConfig{
+			Depth: config.CloneDepth,
+			Paths: []string{constant.VMsDir, constant.SubnetsDir},
+		})
+	}
+
+	urlClient := options.urlClient
+	if urlClient == nil {
+		urlClient = url.NewClient(recorder)
+	}
+
+	var (
+		installer workflow.Installer = workflow.NewVMInstaller(
+			workflow.VMInstallerConfig{
+				Fs:        config.Fs,
+				URLClient: urlClient,
+				Log:       recorder,
+			},
+		)
+		observer = recorder.record
+		m        *metrics.Metrics
+	)
+	if options.metrics != nil {
+		// profiles share the metrics file, so their series are told apart
+		metricsProfile := config.Profile
+		if metricsProfile == "" {
+			metricsProfile = storage.DefaultProfile
+		}
+		m, err = metrics.New(metricsNamespace, prometheus.WrapRegistererWith(
+			prometheus.Labels{profileLabel: metricsProfile},
+			options.metrics,
+		))
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+
+		installer = metrics.NewInstaller(metrics.InstallerConfig{
+			Installer: installer,
+			Metrics:   m,
+			Fs:        config.Fs,
+		})
+		observer = func(operation storage.Operation, err error) {
+			recorder.record(operation, err)
+			m.ObserveOperation(operation, err)
+		}
+	}
+
 	a := &APM{
+		profile:          profile,
 		repositoriesPath: filepath.Join(config.Directory, repositoryDir),
 		tmpPath:          filepath.Join(config.Directory, tmpDir),
+		configBackupPath: filepath.Join(config.Directory, configBackupDir, profile),
 		pluginPath:       config.PluginDir,
 		db:               db,
 		registry:         storage.NewRegistry(db),
 		sourcesList:      storage.NewSourceInfo(db),
-		installedVMs:     storage.NewInstalledVMs(db),
-		auth:             config.Auth,
-		adminAPIEndpoint: config.AdminAPIEndpoint,
-		adminClient:      admin.NewClient(fmt.Sprintf("http://%s", config.AdminAPIEndpoint)),
-		installer: workflow.NewVMInstaller(
-			workflow.VMInstallerConfig{
-				Fs:        config.Fs,
-				URLClient: url.NewClient(),
-			},
-		),
-		executor:    engine.NewWorkflowEngine(),
+		installedVMs:     storage.NewInstalledVMs(profileDB),
+		history:          history,
+		autoUpgradeState: storage.NewAutoUpgrade(profileDB),
+		joinedSubnets:    storage.NewJoinedSubnets(profileDB),
+		credentials:      config.Credentials,
+		adminAPIEndpoint: util.RedactURL(config.AdminAPIEndpoint),
+		loadVMsOnInstall: options.loadVMs,
+		force:            options.force,
+		nodeConfigPath:   config.NodeConfigPath,
+		nodeConfigsDir:   config.NodeConfigsDir,
+		adminClient:      adminClient,
+		infoClient:       infoClient,
+		gitFactory:       gitFactory,
+		installer:        installer,
+		executor: engine.NewWorkflowEngine(engine.Config{
+			History:  history,
+			User:     currentUser(),
+			Profile:  profile,
+			Observer: observer,
+		}),
 		fs:          config.Fs,
+		log:         recorder,
+		recorder:    recorder,
+		metrics:     m,
 		repoFactory: storage.NewRepositoryFactory(db),
 	}
 	if err := os.MkdirAll(a.repositoriesPath, perms.ReadWriteExecute); err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	// TODO simplify this
+	if options.bootstrap {
+		if err := a.bootstrap(); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	a.updateGauges()
+	return a, nil
+}
+
+// Close releases the apm's database. The APM can't be used afterwards.
+func (a *APM) Close() error {
+	if a.closed {
+		return nil
+	}
+
+	a.closed = true
+	return a.db.Close()
+}
+
+// Bootstrap adds the core repository if it isn't tracked and syncs it if it
+// hasn't been synced yet.
+func (a *APM) Bootstrap() (Result, error) {
+	return a.run(a.bootstrap)
+}
+
+func (a *APM) bootstrap() error {
 	coreKey := []byte(constant.CoreAlias)
 	if ok, err := a.sourcesList.Has(coreKey); err != nil {
-		return nil, err
+		return err
 	} else if !ok {
-		err := a.AddRepository(constant.CoreAlias, constant.CoreURL, constant.CoreBranch)
+		err := a.addRepository(constant.CoreAlias, constant.CoreURL, storage.NewBranchRef(constant.CoreBranch))
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	repoMetadata, err := a.sourcesList.Get(coreKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if repoMetadata.Commit == plumbing.ZeroHash {
-		fmt.Println("Bootstrap not detected. Bootstrapping...")
-		err := a.Update()
-		if err != nil {
-			return nil, err
-		}
+	if repoMetadata.Commit != plumbing.ZeroHash {
+		return nil
+	}
 
-		fmt.Println("Finished bootstrapping.")
+	a.log.Info("Bootstrap not detected. Bootstrapping...")
+	if err := a.update(); err != nil {
+		return err
 	}
-	return a, nil
+
+	a.log.Info("Finished bootstrapping.")
+	return nil
 }
 
 func parseAndRun(alias string, registry storage.Storage[storage.RepoList], command func(string) error) error {
-	if qualifiedName(alias) {
+	if util.QualifiedName(alias) {
 		return command(alias)
 	}
 
@@ -142,21 +319,104 @@ func parseAndRun(alias string, registry storage.Storage[storage.RepoList], comma
 	return command(fullName)
 }
 
-func (a *APM) Install(alias string) error {
-	return parseAndRun(alias, a.registry, a.install)
+// run collects the result of a call that modifies the apm's state.
+func (a *APM) run(command func() error) (Result, error) {
+	if a.closed {
+		return Result{}, ErrClosed
+	}
+
+	a.recorder.start()
+	a.nodeVersion, a.nodeVersionAsked = nil, false
+	err := command()
+	a.updateGauges()
+
+	return a.recorder.result(), err
 }
 
-func (a *APM) install(name string) error {
-	nameBytes := []byte(name)
+// runAndLoadVMs is run for calls that install vms. If any were installed,
+// the node is asked to load them unless WithoutLoadingVMs was given.
+func (a *APM) runAndLoadVMs(command func() error) (Result, error) {
+	return a.run(func() error {
+		if err := command(); err != nil {
+			return err
+		}
+		if !a.loadVMsOnInstall || a.recorder.installed() == 0 {
+			return nil
+		}
+
+		return a.loadVMs()
+	})
+}
+
+// updateGauges reports the number of installed vms and how many of them can
+// be upgraded, if metrics are enabled. Metrics are best-effort, so failures
+// are only logged.
+func (a *APM) updateGauges() {
+	if a.metrics == nil {
+		return
+	}
 
-	ok, err := a.installedVMs.Has(nameBytes)
+	installed, pending, err := a.countInstalledVMs()
 	if err != nil {
-		return err
+		a.log.Debug("Failed to count installed vms for metrics: %s", err)
+		return
 	}
 
-	if ok {
-		fmt.Printf("VM %s is already installed. Skipping.\n", name)
-		return nil
+	a.metrics.SetInstalledVMs(installed)
+	a.metrics.SetPendingUpgrades(pending)
+}
+
+func (a *APM) countInstalledVMs() (int, int, error) {
+	itr := a.installedVMs.Iterator()
+	defer itr.Release()
+
+	installed, pending := 0, 0
+	for itr.Next() {
+		installInfo, err := itr.Value()
+		if err != nil {
+			return 0, 0, err
+		}
+		installed++
+
+		repoAlias, plugin := util.ParseQualifiedName(string(itr.Key()))
+		repository := a.repoFactory.GetRepository([]byte(repoAlias))
+		definition, err := repository.VMs.Get([]byte(plugin))
+		if err == database.ErrNotFound {
+			continue
+		} else if err != nil {
+			return 0, 0, err
+		}
+
+		if installInfo.Version.Compare(&definition.Definition.Version) < 0 {
+			pending++
+		}
+	}
+
+	return installed, pending, itr.Error()
+}
+
+func (a *APM) Install(alias string) (Result, error) {
+	return a.runAndLoadVMs(func() error {
+		return parseAndRun(alias, a.registry, a.install)
+	})
+}
+
+func (a *APM) install(name string) error {
+	nameBytes := []byte(name)
+
+	installInfo, err := a.installedVMs.Get(nameBytes)
+	switch {
+	case err == nil && installInfo.Automatic:
+		// like apt, asking for a vm a subnet pulled in keeps it around
+		return a.executor.Execute(workflow.NewMarkManual(workflow.MarkManualConfig{
+			Name:         name,
+			InstalledVMs: a.installedVMs,
+			Log:          a.log,
+		}))
+	case err == nil:
+		return fmt.Errorf("%w: %s", workflow.ErrAlreadyInstalled, name)
+	case err != database.ErrNotFound:
+		return err
 	}
 
 	repoAlias, plugin := util.ParseQualifiedName(name)
@@ -165,23 +425,27 @@ func (a *APM) install(name string) error {
 	repository := a.repoFactory.GetRepository([]byte(repoAlias))
 
 	workflow := workflow.NewInstall(workflow.InstallConfig{
-		Name:         name,
-		Plugin:       plugin,
-		Organization: organization,
-		Repo:         repo,
-		TmpPath:      a.tmpPath,
-		PluginPath:   a.pluginPath,
-		InstalledVMs: a.installedVMs,
-		VMStorage:    repository.VMs,
-		Fs:           a.fs,
-		Installer:    a.installer,
+		Name:          name,
+		Plugin:        plugin,
+		Organization:  organization,
+		Repo:          repo,
+		Compatibility: a.compatibility(),
+		TmpPath:       a.tmpPath,
+		PluginPath:    a.pluginPath,
+		InstalledVMs:  a.installedVMs,
+		VMStorage:     repository.VMs,
+		Fs:            a.fs,
+		Log:           a.log,
+		Installer:     a.installer,
 	})
 
 	return a.executor.Execute(workflow)
 }
 
-func (a *APM) Uninstall(alias string) error {
-	return parseAndRun(alias, a.registry, a.uninstall)
+func (a *APM) Uninstall(alias string) (Result, error) {
+	return a.run(func() error {
+		return parseAndRun(alias, a.registry, a.uninstall)
+	})
 }
 
 func (a *APM) uninstall(name string) error {
@@ -197,77 +461,228 @@ func (a *APM) uninstall(name string) error {
 			VMStorage:    repository.VMs,
 			InstalledVMs: a.installedVMs,
 			Fs:           a.fs,
+			Log:          a.log,
 			PluginPath:   a.pluginPath,
 		},
 	)
 
-	return wf.Execute()
+	return a.executor.Execute(wf)
 }
 
-func (a *APM) JoinSubnet(alias string) error {
-	return parseAndRun(alias, a.registry, a.joinSubnet)
+func (a *APM) JoinSubnet(alias string) (Result, error) {
+	return a.runAndLoadVMs(func() error {
+		return parseAndRun(alias, a.registry, a.joinSubnet)
+	})
 }
 
 func (a *APM) joinSubnet(fullName string) error {
-	alias, plugin := util.ParseQualifiedName(fullName)
-	repoRegistry := a.repoFactory.GetRepository([]byte(alias))
+	alias, _ := util.ParseQualifiedName(fullName)
+
+	nodeConfig, nodeConfigs := a.nodeConfigs()
+	wf := workflow.NewJoinSubnet(workflow.JoinSubnetConfig{
+		Executor:      a.executor,
+		FullName:      fullName,
+		Repository:    a.repoFactory.GetRepository([]byte(alias)),
+		InstalledVMs:  a.installedVMs,
+		JoinedSubnets: a.joinedSubnets,
+		Compatibility: a.compatibility(),
+		TmpPath:       a.tmpPath,
+		PluginPath:    a.pluginPath,
+		Installer:     a.installer,
+		Fs:            a.fs,
+		Log:           a.log,
+		NodeConfig:    nodeConfig,
+		NodeConfigs:   nodeConfigs,
+	})
 
-	var (
-		definition storage.Definition[types.Subnet]
-		err        error
-	)
+	return a.executor.Execute(wf)
+}
 
-	definition, err = repoRegistry.Subnets.Get([]byte(plugin))
-	if err != nil {
+// SubnetConfigChanges returns how joining the subnet with [alias] would change
+// the node's subnet and chain configs. Nothing is written.
+func (a *APM) SubnetConfigChanges(alias string) ([]node.Change, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	var changes []node.Change
+	err := parseAndRun(alias, a.registry, func(fullName string) error {
+		repoAlias, _ := util.ParseQualifiedName(fullName)
+		_, nodeConfigs := a.nodeConfigs()
+
+		var err error
+		changes, err = workflow.NewJoinSubnet(workflow.JoinSubnetConfig{
+			FullName:    fullName,
+			Repository:  a.repoFactory.GetRepository([]byte(repoAlias)),
+			NodeConfigs: nodeConfigs,
+			Log:         a.log,
+		}).ConfigChanges()
 		return err
+	})
+
+	return changes, err
+}
+
+// LeaveSubnet stops tracking the subnet with [alias], and uninstalls the vms
+// that were only installed for it.
+func (a *APM) LeaveSubnet(alias string) (Result, error) {
+	return a.run(func() error {
+		return parseAndRun(alias, a.registry, a.leaveSubnet)
+	})
+}
+
+func (a *APM) leaveSubnet(fullName string) error {
+	alias, _ := util.ParseQualifiedName(fullName)
+
+	nodeConfig, nodeConfigs := a.nodeConfigs()
+	wf := workflow.NewLeaveSubnet(workflow.LeaveSubnetConfig{
+		Executor:      a.executor,
+		FullName:      fullName,
+		Repository:    a.repoFactory.GetRepository([]byte(alias)),
+		InstalledVMs:  a.installedVMs,
+		JoinedSubnets: a.joinedSubnets,
+		PluginPath:    a.pluginPath,
+		Fs:            a.fs,
+		NodeConfig:    nodeConfig,
+		NodeConfigs:   nodeConfigs,
+		Log:           a.log,
+	})
+
+	return a.executor.Execute(wf)
+}
+
+// nodeConfigs returns the node's config file and config directory, or nil
+// for the ones that weren't given.
+func (a *APM) nodeConfigs() (*node.Config, *node.Configs) {
+	var (
+		nodeConfig  *node.Config
+		nodeConfigs *node.Configs
+	)
+	if a.nodeConfigPath != "" {
+		nodeConfig = node.NewConfig(a.fs, a.nodeConfigPath)
+	}
+	if a.nodeConfigsDir != "" {
+		nodeConfigs = node.NewConfigs(a.fs, a.nodeConfigsDir, a.configBackupPath)
 	}
 
-	subnet := definition.Definition
+	return nodeConfig, nodeConfigs
+}
 
-	// TODO prompt user, add force flag
-	fmt.Printf("Installing virtual machines for subnet %s.\n", subnet.GetID())
-	for _, vm := range subnet.VMs {
-		if err := a.Install(strings.Join([]string{alias, vm}, constant.QualifiedNameDelimiter)); err != nil {
-			return err
-		}
+// compatibility checks vms against the node before they're installed. The
+// node is asked for its version the first time a vm is checked in a call. If
+// it can't be reached, vms aren't checked.
+func (a *APM) compatibility() workflow.Compatibility {
+	return workflow.Compatibility{
+		NodeVersion: a.getNodeVersion,
+		Force:       a.force,
 	}
+}
 
-	fmt.Printf("Updating virtual machines...\n")
-	if err := a.adminClient.LoadVMs(); errors.Is(err, syscall.ECONNREFUSED) {
-		fmt.Printf("Node at %s was offline. Virtual machines will be available upon node startup.\n", a.adminAPIEndpoint)
-	} else if err != nil {
-		return err
+func (a *APM) getNodeVersion() *admin.NodeVersion {
+	if a.nodeVersionAsked {
+		return a.nodeVersion
 	}
+	a.nodeVersionAsked = true
 
-	fmt.Printf("Whitelisting subnet %s...\n", subnet.GetID())
-	if err := a.adminClient.WhitelistSubnet(subnet.GetID()); errors.Is(err, syscall.ECONNREFUSED) {
-		fmt.Printf("Node at %s was offline. You'll need to whitelist the subnet upon node restart.\n", a.adminAPIEndpoint)
-	} else if err != nil {
-		return err
+	nodeVersion, err := a.infoClient.GetNodeVersion()
+	if err != nil {
+		a.log.Warn("Couldn't get the node's version, so vms won't be checked for compatibility: %s", err)
+		return nil
 	}
 
-	fmt.Printf("Finished installing virtual machines for subnet %s.\n", subnet.ID)
-	return nil
+	a.log.Debug("Node is running %s with rpcchainvm protocol %d.", nodeVersion.Version, nodeVersion.RPCChainVMProtocol)
+	a.nodeVersion = &nodeVersion
+	return a.nodeVersion
 }
 
-func (a *APM) Info(alias string) error {
-	if qualifiedName(alias) {
-		return a.install(alias)
+// Info describes a vm and whether it's installed.
+func (a *APM) Info(alias string) (VMInfo, error) {
+	if a.closed {
+		return VMInfo{}, ErrClosed
 	}
 
-	fullName, err := getFullNameForAlias(a.registry, alias)
-	if err != nil {
+	var info VMInfo
+	err := parseAndRun(alias, a.registry, func(fullName string) error {
+		var err error
+		info, err = a.info(fullName)
 		return err
+	})
+
+	return info, err
+}
+
+func (a *APM) info(fullName string) (VMInfo, error) {
+	repoAlias, plugin := util.ParseQualifiedName(fullName)
+	repository := a.repoFactory.GetRepository([]byte(repoAlias))
+
+	definition, err := repository.VMs.Get([]byte(plugin))
+	if err == database.ErrNotFound {
+		return VMInfo{}, fmt.Errorf("%w: %s", ErrUnknownAlias, fullName)
+	} else if err != nil {
+		return VMInfo{}, err
+	}
+
+	vm := definition.Definition
+	info := VMInfo{
+		Name:        fullName,
+		ID:          vm.ID,
+		Description: vm.Description,
+		Homepage:    vm.Homepage,
+		Maintainers: vm.Maintainers,
+		Version:     vm.Version,
+		Commit:      definition.Commit.String(),
+
+		RPCChainVMProtocol: vm.RPCChainVMProtocol,
+		MinNodeVersion:     vm.MinNodeVersion,
+		MaxNodeVersion:     vm.MaxNodeVersion,
 	}
 
-	return a.info(fullName)
+	installInfo, err := a.installedVMs.Get([]byte(fullName))
+	if err == nil {
+		info.InstalledVersion = &installInfo.Version
+		info.Held = installInfo.Held
+		info.Constraint = installInfo.Constraint
+	} else if err != database.ErrNotFound {
+		return VMInfo{}, err
+	}
+
+	return info, nil
 }
 
-func (a *APM) info(fullName string) error {
-	return nil
+// Hold stops the vm with [alias] from being upgraded. If [constraint] isn't
+// empty, the vm is instead only upgraded to versions satisfying it.
+func (a *APM) Hold(alias string, constraint string) (Result, error) {
+	return a.run(func() error {
+		return parseAndRun(alias, a.registry, func(name string) error {
+			return a.hold(name, constraint == "", constraint)
+		})
+	})
 }
 
-func (a *APM) Update() error {
+// Unhold lets the vm with [alias] be upgraded to any version again.
+func (a *APM) Unhold(alias string) (Result, error) {
+	return a.run(func() error {
+		return parseAndRun(alias, a.registry, func(name string) error {
+			return a.hold(name, false, "")
+		})
+	})
+}
+
+func (a *APM) hold(name string, held bool, constraint string) error {
+	return a.executor.Execute(workflow.NewHold(workflow.HoldConfig{
+		Name:         name,
+		Held:         held,
+		Constraint:   constraint,
+		InstalledVMs: a.installedVMs,
+		Log:          a.log,
+	}))
+}
+
+func (a *APM) Update() (Result, error) {
+	return a.run(a.update)
+}
+
+func (a *APM) update() error {
 	workflow := workflow.NewUpdate(workflow.UpdateConfig{
 		Executor:         a.executor,
 		Registry:         a.registry,
@@ -278,10 +693,11 @@ func (a *APM) Update() error {
 		PluginPath:       a.pluginPath,
 		Installer:        a.installer,
 		RepositoriesPath: a.repositoriesPath,
-		Auth:             a.auth,
-		GitFactory:       git.RepositoryFactory{},
-		RepoFactory:      storage.NewRepositoryFactory(a.db),
+		Credentials:      a.credentials,
+		GitFactory:       a.gitFactory,
+		RepoFactory:      a.repoFactory,
 		Fs:               a.fs,
+		Log:              a.log,
 	})
 
 	if err := a.executor.Execute(workflow); err != nil {
@@ -291,46 +707,81 @@ func (a *APM) Update() error {
 	return nil
 }
 
-func (a *APM) Upgrade(alias string) error {
+// Upgrade upgrades the vm with [alias], or every installed vm if [alias] is
+// empty.
+func (a *APM) Upgrade(alias string, opts ...UpgradeOption) (Result, error) {
+	options := newUpgradeOptions(opts)
+
+	return a.runAndLoadVMs(func() error {
+		return a.upgrade(alias, options)
+	})
+}
+
+func (a *APM) upgrade(alias string, options *upgradeOptions) error {
 	// If we have an alias specified, upgrade the specified VM.
 	if alias != "" {
-		return parseAndRun(alias, a.registry, a.upgradeVM)
+		return parseAndRun(alias, a.registry, func(name string) error {
+			return a.upgradeVM(name, options)
+		})
 	}
 
 	// Otherwise, just upgrade everything.
 	wf := workflow.NewUpgrade(workflow.UpgradeConfig{
-		Executor:     a.executor,
-		RepoFactory:  a.repoFactory,
-		Registry:     a.registry,
-		SourcesList:  a.sourcesList,
-		InstalledVMs: a.installedVMs,
-		TmpPath:      a.tmpPath,
-		PluginPath:   a.pluginPath,
-		Installer:    a.installer,
-		Fs:           a.fs,
+		Executor:       a.executor,
+		RepoFactory:    a.repoFactory,
+		Registry:       a.registry,
+		SourcesList:    a.sourcesList,
+		InstalledVMs:   a.installedVMs,
+		MaxLevel:       options.maxLevel,
+		Exclude:        options.exclude,
+		AllowDowngrade: options.allowDowngrade,
+		Compatibility:  a.compatibility(),
+		TmpPath:        a.tmpPath,
+		PluginPath:     a.pluginPath,
+		Installer:      a.installer,
+		Fs:             a.fs,
+		Log:            a.log,
 	})
 
 	return a.executor.Execute(wf)
 }
 
-func (a *APM) upgradeVM(name string) error {
-	return a.executor.Execute(workflow.NewUpgradeVM(
+func (a *APM) upgradeVM(name string, options *upgradeOptions) error {
+	err := a.executor.Execute(workflow.NewUpgradeVM(
 		workflow.UpgradeVMConfig{
-			Executor:     a.executor,
-			FullVMName:   name,
-			RepoFactory:  a.repoFactory,
-			InstalledVMs: a.installedVMs,
-			TmpPath:      a.tmpPath,
-			PluginPath:   a.pluginPath,
-			Installer:    a.installer,
-			Fs:           a.fs,
+			Executor:       a.executor,
+			FullVMName:     name,
+			RepoFactory:    a.repoFactory,
+			InstalledVMs:   a.installedVMs,
+			MaxLevel:       options.maxLevel,
+			AllowDowngrade: options.allowDowngrade,
+			Compatibility:  a.compatibility(),
+			TmpPath:        a.tmpPath,
+			PluginPath:     a.pluginPath,
+			Installer:      a.installer,
+			Fs:             a.fs,
+			Log:            a.log,
 		},
 	))
+	// ErrAlreadyUpdated is how the workflow reports that it's done, whether or
+	// not anything was upgraded. A vm that was asked for by name but can't be
+	// changed fails with workflow.ErrUpgradeSkipped instead.
+	if errors.Is(err, workflow.ErrAlreadyUpdated) {
+		return nil
+	}
+
+	return err
+}
+
+func (a *APM) AddRepository(alias string, url string, ref storage.Ref) (Result, error) {
+	return a.run(func() error {
+		return a.addRepository(alias, url, ref)
+	})
 }
 
-func (a *APM) AddRepository(alias string, url string, branch string) error {
+func (a *APM) addRepository(alias string, url string, ref storage.Ref) error {
 	if !util.ValidAlias(alias) {
-		return fmt.Errorf("%s is not a valid alias (must be in the form of organization/repository)", alias)
+		return fmt.Errorf("%w: %s must be in the form of organization/repository", ErrInvalidRepositoryAlias, alias)
 	}
 
 	wf := workflow.NewAddRepository(
@@ -338,75 +789,155 @@ func (a *APM) AddRepository(alias string, url string, branch string) error {
 			SourcesList: a.sourcesList,
 			Alias:       alias,
 			URL:         url,
-			Branch:      plumbing.NewBranchReferenceName(branch),
+			Ref:         ref,
 		},
 	)
 
 	return a.executor.Execute(wf)
 }
 
-func (a *APM) RemoveRepository(alias string) error {
-	if alias == constant.CoreAlias {
-		fmt.Printf("Can't remove %s (required repository).\n", constant.CoreAlias)
-		return nil
+// SetRef changes what the repository [alias] tracks.
+func (a *APM) SetRef(alias string, ref storage.Ref) (Result, error) {
+	return a.run(func() error {
+		return a.executor.Execute(workflow.NewSetRef(workflow.SetRefConfig{
+			SourcesList: a.sourcesList,
+			Alias:       alias,
+			Ref:         ref,
+			Log:         a.log,
+		}))
+	})
+}
+
+func (a *APM) RemoveRepository(alias string) (Result, error) {
+	return a.run(func() error {
+		return a.removeRepository(alias)
+	})
+}
+
+func (a *APM) removeRepository(alias string) error {
+	wf := workflow.NewRemoveRepository(workflow.RemoveRepositoryConfig{
+		SourcesList: a.sourcesList,
+		Repository:  a.repoFactory.GetRepository([]byte(alias)),
+		Alias:       alias,
+		Log:         a.log,
+	})
+
+	return a.executor.Execute(wf)
+}
+
+func (a *APM) ListRepositories() ([]Repository, error) {
+	if a.closed {
+		return nil, ErrClosed
 	}
 
-	aliasBytes := []byte(alias)
-	repoRegistry := a.repoFactory.GetRepository(aliasBytes)
+	itr := a.sourcesList.Iterator()
+	defer itr.Release()
 
-	// delete all the plugin definitions in the repository
-	vmItr := repoRegistry.VMs.Iterator()
-	defer vmItr.Release()
+	result := []Repository{}
+	for itr.Next() {
+		metadata, err := itr.Value()
+		if err != nil {
+			return nil, err
+		}
 
-	for vmItr.Next() {
-		if err := repoRegistry.VMs.Delete(vmItr.Key()); err != nil {
-			return err
+		organization, repo := util.ParseAlias(metadata.Alias)
+		diskUsage, err := a.diskUsage(filepath.Join(a.repositoriesPath, organization, repo))
+		if err != nil {
+			return nil, err
 		}
+
+		ref := metadata.TrackedRef()
+		result = append(result, Repository{
+			Alias:     metadata.Alias,
+			URL:       util.RedactURL(metadata.URL),
+			Branch:    metadata.Branch.Short(),
+			Commit:    metadata.Commit.String(),
+			Ref:       ref.String(),
+			Tag:       metadata.Tag,
+			DiskUsage: diskUsage,
+		})
 	}
 
-	subnetItr := repoRegistry.Subnets.Iterator()
-	defer subnetItr.Release()
+	return result, itr.Error()
+}
 
-	for subnetItr.Next() {
-		if err := repoRegistry.Subnets.Delete(subnetItr.Key()); err != nil {
+// diskUsage returns the size of the files under [path], which is 0 if it
+// doesn't exist.
+func (a *APM) diskUsage(path string) (uint64, error) {
+	var size uint64
+	err := afero.Walk(a.fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
 			return err
 		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
 	}
+	return size, err
+}
 
-	// remove it from our list of tracked repositories
-	return a.sourcesList.Delete(aliasBytes)
+// HistoryFilter selects which operations History returns. The zero value
+// selects everything.
+type HistoryFilter struct {
+	// VM is either a vm alias or a fully qualified vm name.
+	VM    string
+	Since time.Time
 }
 
-func (a *APM) ListRepositories() error {
-	itr := a.sourcesList.Iterator()
+// History returns the recorded operations matching filter, oldest first.
+func (a *APM) History(filter HistoryFilter) ([]storage.Operation, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	itr := a.history.Iterator()
+	defer itr.Release()
 
-	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
-	fmt.Fprintln(w, "alias\turl\tbranch")
+	result := []storage.Operation{}
 	for itr.Next() {
-		metadata, err := itr.Value()
+		operation, err := itr.Value()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\n", metadata.Alias, metadata.URL, metadata.Branch)
+		if operation.StartTime.Before(filter.Since) {
+			continue
+		}
+		if filter.VM != "" && !util.MatchesVM(operation.Name, filter.VM) {
+			continue
+		}
+
+		result = append(result, operation)
 	}
-	w.Flush()
-	return nil
+
+	return result, itr.Error()
 }
 
-func qualifiedName(name string) bool {
-	parsed := strings.Split(name, ":")
-	return len(parsed) > 1
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
 }
 
 func getFullNameForAlias(registry storage.Storage[storage.RepoList], alias string) (string, error) {
 	repoList, err := registry.Get([]byte(alias))
-	if err != nil {
+	if err == database.ErrNotFound || (err == nil && len(repoList.Repositories) == 0) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownAlias, alias)
+	} else if err != nil {
 		return "", err
 	}
 
 	if len(repoList.Repositories) > 1 {
-		return "", fmt.Errorf("more than one match found for %s. Please specify the fully qualified name. Matches: %s", alias, repoList.Repositories)
+		return "", &AmbiguousAliasError{
+			Alias:   alias,
+			Matches: repoList.Repositories,
+		}
 	}
 
 	return fmt.Sprintf("%s:%s", repoList.Repositories[0], alias), nil
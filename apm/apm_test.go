@@ -0,0 +1,63 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database/memdb"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func TestListRepositoriesDiskUsage(t *testing.T) {
+	const repositoriesPath = "/apm/repositories"
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  uint64
+	}{
+		{
+			name: "missing checkout",
+			want: 0,
+		},
+		{
+			name: "populated checkout",
+			files: map[string]string{
+				"/apm/repositories/organization/repository/vms/vm.yaml":          "vm",
+				"/apm/repositories/organization/repository/subnets/subnet.yaml":  "subnet",
+				"/apm/repositories/organization/repository/.git/objects/pack/ab": "pack",
+				"/apm/repositories/organization/other/vms/vm.yaml":               "not counted",
+			},
+			want: uint64(len("vm") + len("subnet") + len("pack")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, contents := range test.files {
+				assert.NoError(t, afero.WriteFile(fs, path, []byte(contents), 0o600))
+			}
+
+			a := &APM{
+				sourcesList:      storage.NewSourceInfo(memdb.New()),
+				repositoriesPath: repositoriesPath,
+				fs:               fs,
+			}
+			assert.NoError(t, a.sourcesList.Put([]byte("organization/repository"), storage.SourceInfo{
+				Alias: "organization/repository",
+				URL:   "https://github.com/organization/repository",
+			}))
+
+			repositories, err := a.ListRepositories()
+			assert.NoError(t, err)
+			assert.Len(t, repositories, 1)
+			assert.Equal(t, test.want, repositories[0].DiskUsage)
+		})
+	}
+}
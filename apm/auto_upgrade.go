@@ -0,0 +1,137 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"time"
+
+	"github.com/MetalBlockchain/metalgo/database"
+
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+// UpgradeOption restricts what Upgrade changes.
+type UpgradeOption func(*upgradeOptions)
+
+type upgradeOptions struct {
+	maxLevel       workflow.Level
+	exclude        []string
+	allowDowngrade bool
+}
+
+func newUpgradeOptions(opts []UpgradeOption) *upgradeOptions {
+	o := &upgradeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// MaxLevel skips upgrades that are a larger version change than [level].
+func MaxLevel(level workflow.Level) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.maxLevel = level
+	}
+}
+
+// Exclude skips the vms with any of the aliases or fully qualified names in
+// [vms] when upgrading everything.
+func Exclude(vms ...string) UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.exclude = append(o.exclude, vms...)
+	}
+}
+
+// AllowDowngrade makes vms follow their repository's version even if it's
+// older than the installed one.
+func AllowDowngrade() UpgradeOption {
+	return func(o *upgradeOptions) {
+		o.allowDowngrade = true
+	}
+}
+
+// AutoUpgradePolicy restricts what AutoUpgrade changes.
+type AutoUpgradePolicy struct {
+	// MaxLevel is the largest version change allowed.
+	MaxLevel workflow.Level
+	// Exclude are the aliases or fully qualified names of vms that aren't
+	// upgraded.
+	Exclude []string
+	// LoadVMs asks the node to load the upgraded vms.
+	LoadVMs bool
+}
+
+// AutoUpgrade updates the tracked repositories and upgrades the installed vms
+// under [policy]. What it did is saved, and returned by AutoUpgradeState.
+func (a *APM) AutoUpgrade(policy AutoUpgradePolicy) (Result, error) {
+	return a.run(func() error {
+		return a.autoUpgrade(policy)
+	})
+}
+
+func (a *APM) autoUpgrade(policy AutoUpgradePolicy) error {
+	state := storage.AutoUpgradeState{
+		LastRun: time.Now(),
+	}
+	previous, err := a.AutoUpgradeState()
+	if err != nil {
+		return err
+	}
+	state.LastSuccess = previous.LastSuccess
+
+	err = a.update()
+	if err == nil {
+		err = a.upgrade("", &upgradeOptions{
+			maxLevel: policy.MaxLevel,
+			exclude:  policy.Exclude,
+		})
+	}
+
+	state.Upgraded = a.recorder.upgraded()
+	if err == nil && state.Upgraded > 0 && policy.LoadVMs {
+		err = a.loadVMs()
+	}
+
+	if err != nil {
+		state.Error = err.Error()
+	} else {
+		state.LastSuccess = state.LastRun
+	}
+
+	if putErr := a.autoUpgradeState.Put(storage.AutoUpgradeStateKey, state); putErr != nil && err == nil {
+		return putErr
+	}
+
+	return err
+}
+
+// AutoUpgradeState returns what the last AutoUpgrade did. The zero value is
+// returned if it never ran.
+func (a *APM) AutoUpgradeState() (storage.AutoUpgradeState, error) {
+	if a.closed {
+		return storage.AutoUpgradeState{}, ErrClosed
+	}
+
+	state, err := a.autoUpgradeState.Get(storage.AutoUpgradeStateKey)
+	if err == database.ErrNotFound {
+		return storage.AutoUpgradeState{}, nil
+	}
+
+	return state, err
+}
+
+func (a *APM) loadVMs() error {
+	wf := workflow.NewLoadVMs(workflow.LoadVMsConfig{
+		InstalledVMs:     a.installedVMs,
+		AdminClient:      a.adminClient,
+		AdminAPIEndpoint: a.adminAPIEndpoint,
+		Log:              a.log,
+	})
+	err := a.executor.Execute(wf)
+	a.recorder.loaded(wf.Result())
+
+	return err
+}
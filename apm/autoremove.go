@@ -0,0 +1,68 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"fmt"
+
+	"github.com/MetalBlockchain/metalgo/database"
+
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+// Why explains why the vm with [alias] is installed.
+func (a *APM) Why(alias string) (InstallReason, error) {
+	if a.closed {
+		return InstallReason{}, ErrClosed
+	}
+
+	var reason InstallReason
+	err := parseAndRun(alias, a.registry, func(name string) error {
+		installInfo, err := a.installedVMs.Get([]byte(name))
+		if err == database.ErrNotFound {
+			return fmt.Errorf("%w: %s", workflow.ErrNotInstalled, name)
+		} else if err != nil {
+			return err
+		}
+
+		subnets, err := workflow.JoinedSubnets(a.joinedSubnets, installInfo.Subnets)
+		if err != nil {
+			return err
+		}
+
+		reason = InstallReason{
+			Name:     name,
+			Explicit: !installInfo.Automatic,
+			Subnets:  subnets,
+		}
+		return nil
+	})
+
+	return reason, err
+}
+
+// Autoremovable returns the vms that Autoremove would uninstall.
+func (a *APM) Autoremovable() ([]string, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	return workflow.OrphanedVMs(a.installedVMs, a.joinedSubnets)
+}
+
+// Autoremove uninstalls the vms that were installed for subnets, and aren't
+// needed by any joined subnet anymore.
+func (a *APM) Autoremove() (Result, error) {
+	return a.run(func() error {
+		return a.executor.Execute(workflow.NewAutoremove(workflow.AutoremoveConfig{
+			Executor:      a.executor,
+			InstalledVMs:  a.installedVMs,
+			JoinedSubnets: a.joinedSubnets,
+			RepoFactory:   a.repoFactory,
+			PluginPath:    a.pluginPath,
+			Fs:            a.fs,
+			Log:           a.log,
+		}))
+	})
+}
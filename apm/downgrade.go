@@ -0,0 +1,82 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"fmt"
+
+	"github.com/shubhamdubey02/apm/constraint"
+	"github.com/shubhamdubey02/apm/util"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+// Downgrade installs the version of the vm with [alias] that its repository
+// currently defines, which must be older than the installed one. If [to]
+// isn't empty, the repository's version must match it.
+func (a *APM) Downgrade(alias string, to string) (Result, error) {
+	return a.runAndLoadVMs(func() error {
+		return parseAndRun(alias, a.registry, func(name string) error {
+			return a.downgrade(name, to)
+		})
+	})
+}
+
+func (a *APM) downgrade(name string, to string) error {
+	info, err := a.info(name)
+	if err != nil {
+		return err
+	}
+	if info.InstalledVersion == nil {
+		return fmt.Errorf("%w: %s", workflow.ErrNotInstalled, name)
+	}
+
+	if to != "" {
+		c, err := constraint.Parse(to)
+		if err != nil {
+			return err
+		}
+		if !c.Allows(&info.Version) {
+			return fmt.Errorf("%w: the repository of %s defines %s, not %s", ErrVersionUnavailable, name, &info.Version, to)
+		}
+	}
+
+	if info.Version.Compare(info.InstalledVersion) >= 0 {
+		return fmt.Errorf("%w: the repository of %s defines %s, which isn't older than the installed %s", ErrNotADowngrade, name, &info.Version, info.InstalledVersion)
+	}
+
+	return a.upgradeVM(name, &upgradeOptions{allowDowngrade: true})
+}
+
+// Downgrades returns the installed vms whose repository defines an older
+// version than the installed one, which Upgrade would downgrade if
+// AllowDowngrade is given.
+func (a *APM) Downgrades() ([]VMInfo, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	itr := a.installedVMs.Iterator()
+	defer itr.Release()
+
+	result := []VMInfo{}
+	for itr.Next() {
+		name := string(itr.Key())
+		if !util.QualifiedName(name) {
+			continue
+		}
+
+		info, err := a.info(name)
+		if err != nil {
+			// vms no longer in a repository are skipped by upgrades
+			a.log.Debug("Failed to describe %s: %s", name, err)
+			continue
+		}
+
+		if info.Version.Compare(info.InstalledVersion) < 0 {
+			result = append(result, info)
+		}
+	}
+
+	return result, itr.Error()
+}
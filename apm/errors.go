@@ -0,0 +1,51 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownAlias is returned when no tracked repository defines a vm or
+	// subnet with the requested alias.
+	ErrUnknownAlias = errors.New("unknown alias")
+	// ErrAmbiguousAlias is returned when more than one tracked repository
+	// defines the requested alias. Use errors.As with *AmbiguousAliasError to
+	// get the matches.
+	ErrAmbiguousAlias = errors.New("ambiguous alias")
+	// ErrInvalidRepositoryAlias is returned when a repository alias isn't in
+	// the form of organization/repository.
+	ErrInvalidRepositoryAlias = errors.New("invalid repository alias")
+	// ErrVersionUnavailable is returned when a repository doesn't define the
+	// requested version of a vm.
+	ErrVersionUnavailable = errors.New("version unavailable")
+	// ErrNotADowngrade is returned when downgrading a vm whose repository
+	// doesn't define an older version than the installed one.
+	ErrNotADowngrade = errors.New("not a downgrade")
+	// ErrClosed is returned when the apm is used after Close.
+	ErrClosed = errors.New("apm is closed")
+)
+
+// AmbiguousAliasError is returned when an alias is defined by more than one
+// repository, and the fully qualified name must be used instead.
+type AmbiguousAliasError struct {
+	Alias string
+	// Matches are the repositories that define Alias.
+	Matches []string
+}
+
+func (e *AmbiguousAliasError) Error() string {
+	return fmt.Sprintf(
+		"more than one match found for %s. Please specify the fully qualified name. Matches: %s",
+		e.Alias,
+		strings.Join(e.Matches, ", "),
+	)
+}
+
+func (e *AmbiguousAliasError) Is(target error) bool {
+	return target == ErrAmbiguousAlias
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+// ListInstalled returns the vms installed for each of [profiles], or for the
+// apm's profile if none are given.
+func (a *APM) ListInstalled(profiles ...string) ([]InstalledVM, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	if len(profiles) == 0 {
+		profiles = []string{a.profile}
+	}
+
+	result := []InstalledVM{}
+	for _, profile := range profiles {
+		if profile == "" {
+			profile = storage.DefaultProfile
+		}
+
+		installed, err := listInstalled(profile, storage.NewInstalledVMs(storage.NewProfile(a.db, profile)))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, installed...)
+	}
+
+	return result, nil
+}
+
+func listInstalled(profile string, installedVMs storage.Storage[storage.InstallInfo]) ([]InstalledVM, error) {
+	itr := installedVMs.Iterator()
+	defer itr.Release()
+
+	result := []InstalledVM{}
+	for itr.Next() {
+		installInfo, err := itr.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, InstalledVM{
+			Profile:    profile,
+			Name:       string(itr.Key()),
+			ID:         installInfo.ID,
+			Version:    installInfo.Version,
+			Held:       installInfo.Held,
+			Constraint: installInfo.Constraint,
+			Automatic:  installInfo.Automatic,
+			Subnets:    installInfo.Subnets,
+		})
+	}
+
+	return result, itr.Error()
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"errors"
+
+	"github.com/MetalBlockchain/metalgo/database"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/types"
+	"github.com/shubhamdubey02/apm/util"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+// CheckNodeUpgrade reports whether each installed vm works with [node], and
+// if not, whether the version its repository defines does. Nothing is
+// changed.
+func (a *APM) CheckNodeUpgrade(node admin.NodeVersion) ([]VMCompatibility, error) {
+	if a.closed {
+		return nil, ErrClosed
+	}
+
+	itr := a.installedVMs.Iterator()
+	defer itr.Release()
+
+	result := []VMCompatibility{}
+	for itr.Next() {
+		name := string(itr.Key())
+		if !util.QualifiedName(name) {
+			continue
+		}
+
+		installInfo, err := itr.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		installed := types.VM{
+			Version:            installInfo.Version,
+			RPCChainVMProtocol: installInfo.RPCChainVMProtocol,
+			MinNodeVersion:     installInfo.MinNodeVersion,
+			MaxNodeVersion:     installInfo.MaxNodeVersion,
+		}
+
+		repoAlias, plugin := util.ParseQualifiedName(name)
+		definition, err := a.repoFactory.GetRepository([]byte(repoAlias)).VMs.Get([]byte(plugin))
+		found := err == nil
+		if err != nil && err != database.ErrNotFound {
+			return nil, err
+		}
+		available := definition.Definition
+
+		// vms installed before their requirements were recorded can use
+		// the definition they were installed from, if it's still current
+		if !hasRequirements(installed) && found && available.Version.Compare(&installInfo.Version) == 0 {
+			installed = available
+		}
+
+		compatibility := VMCompatibility{
+			Name:             name,
+			InstalledVersion: installInfo.Version,
+		}
+		if found && available.Version.Compare(&installInfo.Version) > 0 {
+			compatibility.AvailableVersion = &available.Version
+		}
+
+		reasons := incompatibilities(name, installed, node)
+		switch {
+		case len(reasons) == 0 && hasRequirements(installed):
+			compatibility.Status = Compatible
+		case len(reasons) == 0:
+			compatibility.Status = UnknownCompatibility
+		case compatibility.AvailableVersion == nil:
+			compatibility.Status = NoCompatibleVersion
+		case !hasRequirements(available):
+			// the available version might work, but it doesn't say
+			compatibility.Status = UnknownCompatibility
+		case len(incompatibilities(name, available, node)) == 0:
+			compatibility.Status = UpgradeRequired
+		default:
+			compatibility.Status = NoCompatibleVersion
+		}
+		compatibility.Reasons = reasons
+
+		result = append(result, compatibility)
+	}
+
+	return result, itr.Error()
+}
+
+// hasRequirements returns true if [vm] says what it needs from the node.
+func hasRequirements(vm types.VM) bool {
+	return vm.RPCChainVMProtocol != 0 || vm.MinNodeVersion != nil || vm.MaxNodeVersion != nil
+}
+
+// incompatibilities returns why [vm] can't run on [node].
+func incompatibilities(name string, vm types.VM, node admin.NodeVersion) []string {
+	var incompatible *workflow.IncompatibleError
+	if err := workflow.Incompatibilities(name, vm, node); errors.As(err, &incompatible) {
+		return incompatible.Reasons
+	}
+
+	return nil
+}
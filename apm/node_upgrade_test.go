@@ -0,0 +1,112 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database/memdb"
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/types"
+)
+
+func TestCheckNodeUpgrade(t *testing.T) {
+	node := admin.NodeVersion{
+		Version:            &version.Semantic{Major: 1, Minor: 8, Patch: 0},
+		RPCChainVMProtocol: 16,
+	}
+	v1 := version.Semantic{Major: 1, Minor: 0, Patch: 0}
+	v2 := version.Semantic{Major: 2, Minor: 0, Patch: 0}
+
+	tests := []struct {
+		name       string
+		installed  storage.InstallInfo
+		available  *types.VM
+		wantStatus NodeCompatibility
+		wantNewer  bool
+	}{
+		{
+			name:       "compatible",
+			installed:  storage.InstallInfo{Version: v1, RPCChainVMProtocol: 16},
+			available:  &types.VM{Version: v1, RPCChainVMProtocol: 16},
+			wantStatus: Compatible,
+		},
+		{
+			name:       "upgrade required",
+			installed:  storage.InstallInfo{Version: v1, RPCChainVMProtocol: 15},
+			available:  &types.VM{Version: v2, RPCChainVMProtocol: 16},
+			wantStatus: UpgradeRequired,
+			wantNewer:  true,
+		},
+		{
+			name:       "no newer version",
+			installed:  storage.InstallInfo{Version: v1, RPCChainVMProtocol: 15},
+			available:  &types.VM{Version: v1, RPCChainVMProtocol: 15},
+			wantStatus: NoCompatibleVersion,
+		},
+		{
+			name:       "newer version is incompatible too",
+			installed:  storage.InstallInfo{Version: v1, RPCChainVMProtocol: 15},
+			available:  &types.VM{Version: v2, RPCChainVMProtocol: 17},
+			wantStatus: NoCompatibleVersion,
+			wantNewer:  true,
+		},
+		{
+			name:       "not in its repository anymore",
+			installed:  storage.InstallInfo{Version: v1, RPCChainVMProtocol: 15},
+			wantStatus: NoCompatibleVersion,
+		},
+		{
+			name:       "installed version has no requirements",
+			installed:  storage.InstallInfo{Version: v1},
+			available:  &types.VM{Version: v2, RPCChainVMProtocol: 16},
+			wantStatus: UnknownCompatibility,
+			wantNewer:  true,
+		},
+		{
+			name:       "newer version has no requirements",
+			installed:  storage.InstallInfo{Version: v1, RPCChainVMProtocol: 15},
+			available:  &types.VM{Version: v2},
+			wantStatus: UnknownCompatibility,
+			wantNewer:  true,
+		},
+		{
+			name:       "requirements from the definition it was installed from",
+			installed:  storage.InstallInfo{Version: v1},
+			available:  &types.VM{Version: v1, RPCChainVMProtocol: 16},
+			wantStatus: Compatible,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := memdb.New()
+			a := &APM{
+				installedVMs: storage.NewInstalledVMs(db),
+				repoFactory:  storage.NewRepositoryFactory(db),
+			}
+
+			assert.NoError(t, a.installedVMs.Put([]byte("organization/repository:vm"), test.installed))
+			if test.available != nil {
+				vms := a.repoFactory.GetRepository([]byte("organization/repository")).VMs
+				assert.NoError(t, vms.Put([]byte("vm"), storage.Definition[types.VM]{Definition: *test.available}))
+			}
+
+			result, err := a.CheckNodeUpgrade(node)
+			assert.NoError(t, err)
+			assert.Len(t, result, 1)
+			assert.Equal(t, "organization/repository:vm", result[0].Name)
+			assert.Equal(t, test.wantStatus, result[0].Status)
+			if test.wantNewer {
+				assert.Equal(t, &test.available.Version, result[0].AvailableVersion)
+			} else {
+				assert.Nil(t, result[0].AvailableVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/git"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/url"
+)
+
+// Option customizes an APM created by New.
+type Option func(*options)
+
+type options struct {
+	log         logging.Logger
+	bootstrap   bool
+	adminClient admin.Client
+	infoClient  admin.InfoClient
+	gitFactory  git.Factory
+	urlClient   url.Client
+	metrics     prometheus.Registerer
+	loadVMs     bool
+	force       bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		log:       logging.NoLog{},
+		bootstrap: true,
+		loadVMs:   true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithLogger sets where progress and diagnostics are reported. By default
+// nothing is logged.
+func WithLogger(log logging.Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+// WithOutput reports human-readable progress to [w].
+func WithOutput(w io.Writer) Option {
+	return WithLogger(logging.New(logging.Config{
+		Writer: w,
+		Level:  logging.Info,
+		Format: logging.Text,
+	}))
+}
+
+// WithoutBootstrap stops New from adding and syncing the core repository if
+// it isn't tracked yet. Call Bootstrap to do this later.
+func WithoutBootstrap() Option {
+	return func(o *options) {
+		o.bootstrap = false
+	}
+}
+
+// WithAdminClient sets the client used to talk to the node's admin api,
+// instead of one built from Config.AdminAPIEndpoint.
+func WithAdminClient(client admin.Client) Option {
+	return func(o *options) {
+		o.adminClient = client
+	}
+}
+
+// WithInfoClient sets the client used to ask the node for its version,
+// instead of one built from Config.InfoAPIEndpoint.
+func WithInfoClient(client admin.InfoClient) Option {
+	return func(o *options) {
+		o.infoClient = client
+	}
+}
+
+// WithGitFactory sets how plugin repositories are cloned and synced.
+func WithGitFactory(factory git.Factory) Option {
+	return func(o *options) {
+		o.gitFactory = factory
+	}
+}
+
+// WithURLClient sets the client used to download vm archives.
+func WithURLClient(client url.Client) Option {
+	return func(o *options) {
+		o.urlClient = client
+	}
+}
+
+// WithMetrics registers metrics about the apm's operations with [registerer].
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metrics = registerer
+	}
+}
+
+// WithoutLoadingVMs stops Install, Upgrade, Downgrade and JoinSubnet from
+// asking the node to load the vms they installed. They're loaded when the
+// node restarts instead.
+func WithoutLoadingVMs() Option {
+	return func(o *options) {
+		o.loadVMs = false
+	}
+}
+
+// Force makes Install, Upgrade, Downgrade and JoinSubnet install vms that
+// aren't compatible with the node, with a warning. By default they're
+// refused.
+func Force() Option {
+	return func(o *options) {
+		o.force = true
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package apm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/version"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var _ logging.Logger = &recorder{}
+
+// Result describes what a command that modifies the apm's state did.
+type Result struct {
+	// Operations that were performed, in the order they finished. Operations
+	// triggered by another operation reference it as their parent.
+	Operations []storage.Operation `json:"operations" yaml:"operations"`
+	Warnings   []string            `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// LoadedVMs are the ids of the vms the node loaded, and their aliases.
+	LoadedVMs map[string][]string `json:"loadedVMs,omitempty" yaml:"loadedVMs,omitempty"`
+	// FailedVMs are the ids of the vms the node failed to load, and the
+	// node's error.
+	FailedVMs map[string]string `json:"failedVMs,omitempty" yaml:"failedVMs,omitempty"`
+}
+
+// Repository is a tracked plugin repository.
+type Repository struct {
+	Alias  string `json:"alias" yaml:"alias"`
+	URL    string `json:"url" yaml:"url"`
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Commit string `json:"commit" yaml:"commit"`
+	// Ref is what the repository tracks, e.g. "tag v1.0.0".
+	Ref string `json:"ref" yaml:"ref"`
+	// Tag is the tag the repository was synced to, if it follows the latest
+	// tag.
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	// DiskUsage is the size of the local checkout in bytes.
+	DiskUsage uint64 `json:"diskUsage" yaml:"diskUsage"`
+}
+
+// VMInfo describes a vm available in a tracked repository.
+type VMInfo struct {
+	Name        string           `json:"name" yaml:"name"`
+	ID          string           `json:"id" yaml:"id"`
+	Description string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Homepage    string           `json:"homepage,omitempty" yaml:"homepage,omitempty"`
+	Maintainers []string         `json:"maintainers,omitempty" yaml:"maintainers,omitempty"`
+	Version     version.Semantic `json:"version" yaml:"version"`
+	// Commit is the repository commit the definition was read from.
+	Commit string `json:"commit" yaml:"commit"`
+	// InstalledVersion is nil if the vm isn't installed.
+	InstalledVersion *version.Semantic `json:"installedVersion,omitempty" yaml:"installedVersion,omitempty"`
+	// Held and Constraint restrict upgrades of an installed vm.
+	Held       bool   `json:"held,omitempty" yaml:"held,omitempty"`
+	Constraint string `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	// RPCChainVMProtocol, MinNodeVersion and MaxNodeVersion are what the vm
+	// needs from the node, if its definition says.
+	RPCChainVMProtocol uint              `json:"rpcChainVMProtocol,omitempty" yaml:"rpcChainVMProtocol,omitempty"`
+	MinNodeVersion     *version.Semantic `json:"minNodeVersion,omitempty" yaml:"minNodeVersion,omitempty"`
+	MaxNodeVersion     *version.Semantic `json:"maxNodeVersion,omitempty" yaml:"maxNodeVersion,omitempty"`
+}
+
+// NodeCompatibility is whether an installed vm works with a node version.
+type NodeCompatibility string
+
+const (
+	// Compatible vms work with the node as they're installed.
+	Compatible NodeCompatibility = "compatible"
+	// UpgradeRequired vms don't work with the node as they're installed, but
+	// the version their repository defines does. They must be upgraded
+	// first.
+	UpgradeRequired NodeCompatibility = "upgrade-required"
+	// NoCompatibleVersion vms don't work with the node, and their repository
+	// doesn't define a version that's known to.
+	NoCompatibleVersion NodeCompatibility = "no-compatible-version"
+	// UnknownCompatibility vms don't say what they need from the node, or
+	// don't work with it as they're installed and the version their
+	// repository defines doesn't say.
+	UnknownCompatibility NodeCompatibility = "unknown"
+)
+
+// VMCompatibility is whether an installed vm works with a node version.
+type VMCompatibility struct {
+	Name             string           `json:"name" yaml:"name"`
+	InstalledVersion version.Semantic `json:"installedVersion" yaml:"installedVersion"`
+	// AvailableVersion is the newer version the vm's repository defines, if
+	// there is one.
+	AvailableVersion *version.Semantic `json:"availableVersion,omitempty" yaml:"availableVersion,omitempty"`
+	Status           NodeCompatibility `json:"status" yaml:"status"`
+	// Reasons are why the installed version doesn't work with the node.
+	Reasons []string `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+// InstalledVM is a vm installed for a profile.
+type InstalledVM struct {
+	Profile string           `json:"profile" yaml:"profile"`
+	Name    string           `json:"name" yaml:"name"`
+	ID      string           `json:"id" yaml:"id"`
+	Version version.Semantic `json:"version" yaml:"version"`
+	// Held and Constraint restrict upgrades of the vm.
+	Held       bool   `json:"held,omitempty" yaml:"held,omitempty"`
+	Constraint string `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	// Automatic vms were installed for the joined Subnets.
+	Automatic bool     `json:"automatic,omitempty" yaml:"automatic,omitempty"`
+	Subnets   []string `json:"subnets,omitempty" yaml:"subnets,omitempty"`
+}
+
+// InstallReason explains why a vm is installed.
+type InstallReason struct {
+	Name string `json:"name" yaml:"name"`
+	// Explicit is true if the operator installed the vm, rather than a
+	// subnet.
+	Explicit bool `json:"explicit" yaml:"explicit"`
+	// Subnets are the joined subnets that need the vm.
+	Subnets []string `json:"subnets,omitempty" yaml:"subnets,omitempty"`
+}
+
+// recorder collects the operations and warnings of a single call into the apm
+// while passing log messages through.
+type recorder struct {
+	logging.Logger
+
+	operations []storage.Operation
+	warnings   []string
+	loadedVMs  map[string][]string
+	failedVMs  map[string]string
+}
+
+func (r *recorder) Warn(format string, args ...interface{}) {
+	r.warnings = append(r.warnings, strings.TrimRight(fmt.Sprintf(format, args...), "\n"))
+	r.Logger.Warn(format, args...)
+}
+
+func (r *recorder) record(operation storage.Operation, _ error) {
+	r.operations = append(r.operations, operation)
+}
+
+// upgraded returns the number of vms upgraded so far.
+func (r *recorder) upgraded() int {
+	n := 0
+	for _, operation := range r.operations {
+		if operation.Type == storage.UpgradeOperation && operation.Name != "" && operation.Outcome == storage.Succeeded {
+			n++
+		}
+	}
+
+	return n
+}
+
+// installed returns the number of vms installed, upgraded or downgraded so
+// far.
+func (r *recorder) installed() int {
+	n := 0
+	for _, operation := range r.operations {
+		switch operation.Type {
+		case storage.InstallOperation, storage.UpgradeOperation, storage.DowngradeOperation:
+			if operation.Name != "" && operation.Outcome == storage.Succeeded {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// loaded records what the node loaded.
+func (r *recorder) loaded(result admin.LoadVMsResult) {
+	for id, aliases := range result.NewVMs {
+		if r.loadedVMs == nil {
+			r.loadedVMs = map[string][]string{}
+		}
+		r.loadedVMs[id.String()] = aliases
+	}
+	for id, reason := range result.FailedVMs {
+		if r.failedVMs == nil {
+			r.failedVMs = map[string]string{}
+		}
+		r.failedVMs[id.String()] = reason
+	}
+}
+
+// start discards anything recorded by a previous call.
+func (r *recorder) start() {
+	r.operations = nil
+	r.warnings = nil
+	r.loadedVMs = nil
+	r.failedVMs = nil
+}
+
+func (r *recorder) result() Result {
+	result := Result{
+		Operations: r.operations,
+		Warnings:   r.warnings,
+		LoadedVMs:  r.loadedVMs,
+		FailedVMs:  r.failedVMs,
+	}
+	if result.Operations == nil {
+		result.Operations = []storage.Operation{}
+	}
+
+	return result
+}
@@ -11,7 +11,7 @@ import (
 func addRepository(fs afero.Fs) *cobra.Command {
 	url := ""
 	alias := ""
-	branch := ""
+	refs := &refFlags{}
 
 	command := &cobra.Command{
 		Use:   "add-repository",
@@ -30,19 +30,21 @@ func addRepository(fs afero.Fs) *cobra.Command {
 		panic(err)
 	}
 
-	command.PersistentFlags().StringVar(&branch, "branch", "", "branch name to track")
-	err = command.MarkPersistentFlagRequired("branch")
-	if err != nil {
-		panic(err)
-	}
+	refs.add(command)
 
 	command.RunE = func(_ *cobra.Command, _ []string) error {
+		ref, err := refs.ref()
+		if err != nil {
+			return err
+		}
+
 		apm, err := initAPM(fs)
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
 
-		return apm.AddRepository(alias, url, branch)
+		return renderResult(apm.AddRepository(alias, url, ref))
 	}
 
 	return command
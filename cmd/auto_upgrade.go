@@ -0,0 +1,112 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/scheduler"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+func autoUpgrade(fs afero.Fs) *cobra.Command {
+	var (
+		once     bool
+		interval time.Duration
+		level    string
+		windows  []string
+		exclude  []string
+		jitter   time.Duration
+	)
+
+	command := &cobra.Command{
+		Use:   "auto-upgrade",
+		Short: "Periodically updates repositories and upgrades virtual machines under a policy",
+	}
+	command.PersistentFlags().BoolVar(&once, "once", false, "run at most once, now, instead of periodically")
+	command.PersistentFlags().DurationVar(&interval, "interval", 24*time.Hour, "time between runs")
+	command.PersistentFlags().StringVar(&level, "level", string(workflow.Minor), "largest version change to upgrade to (patch, minor, or major)")
+	command.PersistentFlags().StringSliceVar(&windows, "window", nil, "maintenance window in local time runs may start in, like \"02:00-04:00\" or \"sat,sun 22:00-02:00\". May be repeated")
+	command.PersistentFlags().StringSliceVar(&exclude, "exclude", nil, "vm alias to never upgrade. May be repeated")
+	command.PersistentFlags().DurationVar(&jitter, "jitter", 0, "most a run is randomly delayed by, to spread upgrades across a fleet")
+
+	command.RunE = func(cmd *cobra.Command, _ []string) error {
+		if err := applyUpgradePolicy(cmd.Flags()); err != nil {
+			return err
+		}
+
+		maxLevel, err := workflow.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+
+		parsedWindows := make([]scheduler.Window, 0, len(windows))
+		for _, window := range windows {
+			parsed, err := scheduler.ParseWindow(window)
+			if err != nil {
+				return err
+			}
+			parsedWindows = append(parsedWindows, parsed)
+		}
+
+		if err := requireAdminAPI(fs); err != nil {
+			return err
+		}
+
+		log, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		a, err := newAPM(fs, log)
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+
+		s := scheduler.New(scheduler.Config{
+			APM: a,
+			Policy: apm.AutoUpgradePolicy{
+				MaxLevel: maxLevel,
+				Exclude:  exclude,
+				LoadVMs:  viper.GetBool(loadVMsKey),
+			},
+			Interval: interval,
+			Windows:  parsedWindows,
+			Jitter:   jitter,
+			Log:      log,
+		})
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if once {
+			result, _, err := s.RunOnce(ctx)
+			if result.Operations == nil {
+				result.Operations = []storage.Operation{}
+			}
+
+			return renderResult(result, err)
+		}
+
+		if err := s.Run(ctx); !errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		return nil
+	}
+
+	return command
+}
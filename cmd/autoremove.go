@@ -0,0 +1,46 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func autoremove(fs afero.Fs) *cobra.Command {
+	yes := false
+	command := &cobra.Command{
+		Use: "autoremove",
+		Short: "Uninstalls the virtual machines that were installed for " +
+			"subnets that have all been left.",
+	}
+	command.PersistentFlags().BoolVarP(&yes, "yes", "y", false, "uninstall without asking for confirmation")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		if !yes {
+			vms, err := apm.Autoremovable()
+			if err != nil {
+				return err
+			}
+			if len(vms) > 0 {
+				if err := confirm(fmt.Sprintf("This will uninstall:\n  %s\nContinue?", strings.Join(vms, "\n  "))); err != nil {
+					return err
+				}
+			}
+		}
+
+		return renderResult(apm.Autoremove())
+	}
+
+	return command
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+// staleDefinitionsNote is shown when a newer definition than the last synced
+// one might change the outcome.
+const staleDefinitionsNote = "Available versions are as of the last time repositories were synced, " +
+	"and may be stale. Run `apm update` to check against the latest definitions."
+
+func checkNodeUpgrade(fs afero.Fs) *cobra.Command {
+	nodeVersion := ""
+	protocol := uint(0)
+	command := &cobra.Command{
+		Annotations: map[string]string{
+			readOnlyAnnotation: "true",
+		},
+		Use: "check-node-upgrade",
+		Short: "Reports which installed virtual machines would break if the " +
+			"node was upgraded, and whether they can be upgraded first.",
+	}
+	command.PersistentFlags().StringVar(&nodeVersion, "node-version", "", "metalgo version to check against (e.g. \"1.8.0\")")
+	command.PersistentFlags().UintVar(&protocol, "protocol", 0, "rpcchainvm protocol version of the metalgo version. Not checked if it isn't given")
+	err := command.MarkPersistentFlagRequired("node-version")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		parsed, err := version.Parse("v" + strings.TrimPrefix(nodeVersion, "v"))
+		if err != nil {
+			return err
+		}
+
+		a, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+
+		vms, err := a.CheckNodeUpgrade(admin.NodeVersion{
+			Version:            parsed,
+			RPCChainVMProtocol: protocol,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := render(vms, func(w io.Writer) {
+			fmt.Fprintln(w, "name\tinstalled\tavailable\tstatus\treasons")
+			for _, vm := range vms {
+				available := ""
+				if vm.AvailableVersion != nil {
+					available = vm.AvailableVersion.String()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", vm.Name, &vm.InstalledVersion, available, vm.Status, strings.Join(vm.Reasons, "; "))
+			}
+		}); err != nil {
+			return err
+		}
+
+		// scripts can hold off the node upgrade until nothing would break
+		broken := []string{}
+		stale := false
+		for _, vm := range vms {
+			if vm.Status == apm.UpgradeRequired || vm.Status == apm.NoCompatibleVersion {
+				broken = append(broken, vm.Name)
+			}
+			if vm.Status == apm.NoCompatibleVersion || vm.Status == apm.UnknownCompatibility {
+				stale = true
+			}
+		}
+		if stale {
+			// stderr keeps json and yaml output parseable
+			fmt.Fprintln(os.Stderr, staleDefinitionsNote)
+		}
+		if len(broken) > 0 {
+			return fmt.Errorf("%w: %s would break on %s", workflow.ErrIncompatible, strings.Join(broken, ", "), parsed)
+		}
+
+		return nil
+	}
+
+	return command
+}
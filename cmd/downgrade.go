@@ -0,0 +1,61 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func downgrade(fs afero.Fs) *cobra.Command {
+	vm := ""
+	to := ""
+	yes := false
+	force := false
+	command := &cobra.Command{
+		Use: "downgrade",
+		Short: "Installs the version of a virtual machine that its " +
+			"repository defines, if it's older than the installed one.",
+	}
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to downgrade")
+	command.PersistentFlags().StringVar(&to, "to", "", "version the repository must define (e.g. \"1.4.2\" or \"~1.4\")")
+	command.PersistentFlags().BoolVarP(&yes, "yes", "y", false, "downgrade without asking for confirmation")
+	command.PersistentFlags().BoolVar(&force, "force", false, "downgrade even if the older version isn't compatible with the node")
+	err := command.MarkPersistentFlagRequired("vm")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		if err := requireAdminAPI(fs); err != nil {
+			return err
+		}
+
+		apm, err := initAPM(fs, forceOptions(force)...)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		if !yes {
+			info, err := apm.Info(vm)
+			if err != nil {
+				return err
+			}
+			// otherwise Downgrade explains why it can't downgrade
+			if info.InstalledVersion != nil && info.Version.Compare(info.InstalledVersion) < 0 {
+				question := fmt.Sprintf("Downgrade %s from %s to %s?", info.Name, info.InstalledVersion, &info.Version)
+				if err := confirm(question); err != nil {
+					return err
+				}
+			}
+		}
+
+		return renderResult(apm.Downgrade(vm, to))
+	}
+
+	return command
+}
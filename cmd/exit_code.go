@@ -0,0 +1,68 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+// Exit codes returned by the apm binary. These are part of the apm's
+// interface for scripts, so existing codes must not be renumbered.
+const (
+	ExitSuccess                = 0
+	ExitError                  = 1
+	ExitUnknownAlias           = 2
+	ExitAmbiguousAlias         = 3
+	ExitInvalidRepositoryAlias = 4
+	ExitAlreadyInstalled       = 5
+	ExitChecksumMismatch       = 6
+	ExitDownloadFailed         = 7
+	ExitInstallScriptFailed    = 8
+	ExitAdminAPIOffline        = 9
+	ExitNotInstalled           = 10
+	ExitVersionUnavailable     = 11
+	ExitVMLoadFailed           = 12
+	ExitIncompatible           = 13
+	ExitUpgradeSkipped         = 14
+)
+
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{err: apm.ErrUnknownAlias, code: ExitUnknownAlias},
+	{err: workflow.ErrUnknownVM, code: ExitUnknownAlias},
+	{err: workflow.ErrUnknownSubnet, code: ExitUnknownAlias},
+	{err: apm.ErrAmbiguousAlias, code: ExitAmbiguousAlias},
+	{err: apm.ErrInvalidRepositoryAlias, code: ExitInvalidRepositoryAlias},
+	{err: workflow.ErrAlreadyInstalled, code: ExitAlreadyInstalled},
+	{err: workflow.ErrChecksumMismatch, code: ExitChecksumMismatch},
+	{err: workflow.ErrDownloadFailed, code: ExitDownloadFailed},
+	{err: workflow.ErrInstallScriptFailed, code: ExitInstallScriptFailed},
+	{err: workflow.ErrAdminAPIOffline, code: ExitAdminAPIOffline},
+	{err: workflow.ErrNotInstalled, code: ExitNotInstalled},
+	{err: workflow.ErrNotJoined, code: ExitNotInstalled},
+	{err: apm.ErrVersionUnavailable, code: ExitVersionUnavailable},
+	{err: workflow.ErrVMLoadFailed, code: ExitVMLoadFailed},
+	{err: workflow.ErrIncompatible, code: ExitIncompatible},
+	{err: workflow.ErrUpgradeSkipped, code: ExitUpgradeSkipped},
+}
+
+// ExitCode returns the process exit code for an error returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	for _, exitCode := range exitCodes {
+		if errors.Is(err, exitCode.err) {
+			return exitCode.code
+		}
+	}
+
+	return ExitError
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "success",
+			err:  nil,
+			want: ExitSuccess,
+		},
+		{
+			name: "unexpected error",
+			err:  errors.New("something went wrong"),
+			want: ExitError,
+		},
+		{
+			name: "wrapped unknown alias",
+			err:  fmt.Errorf("%w: vm", apm.ErrUnknownAlias),
+			want: ExitUnknownAlias,
+		},
+		{
+			name: "ambiguous alias",
+			err:  &apm.AmbiguousAliasError{Alias: "vm"},
+			want: ExitAmbiguousAlias,
+		},
+		{
+			name: "already installed",
+			err:  fmt.Errorf("%w: org/repo:vm", workflow.ErrAlreadyInstalled),
+			want: ExitAlreadyInstalled,
+		},
+		{
+			name: "checksum mismatch",
+			err:  &workflow.ChecksumMismatchError{Expected: "a", Actual: "b"},
+			want: ExitChecksumMismatch,
+		},
+		{
+			name: "version unavailable",
+			err:  fmt.Errorf("%w: org/repo:vm", apm.ErrVersionUnavailable),
+			want: ExitVersionUnavailable,
+		},
+		{
+			name: "incompatible",
+			err:  &workflow.IncompatibleError{Name: "org/repo:vm", Reasons: []string{"reason"}},
+			want: ExitIncompatible,
+		},
+		{
+			name: "held",
+			err:  &workflow.SkippedError{Name: "org/repo:vm", Reason: "it's held"},
+			want: ExitUpgradeSkipped,
+		},
+		{
+			name: "skipped for being incompatible",
+			err:  &workflow.SkippedError{Name: "org/repo:vm", Reason: "reason", Incompatible: true},
+			want: ExitIncompatible,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, ExitCode(test.err))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func history(fs afero.Fs) *cobra.Command {
+	vm := ""
+	since := ""
+
+	command := &cobra.Command{
+		Annotations: map[string]string{
+			readOnlyAnnotation: "true",
+		},
+		Use:   "history",
+		Short: "Shows the history of operations performed by the apm.",
+	}
+	command.PersistentFlags().StringVar(&vm, "vm", "", "only show operations on this vm alias")
+	command.PersistentFlags().StringVar(&since, "since", "", "only show operations started after this time (RFC3339) or duration ago (e.g. 24h)")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		filter := apm.HistoryFilter{
+			VM: vm,
+		}
+		if since != "" {
+			sinceTime, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			filter.Since = sinceTime
+		}
+
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		operations, err := apm.History(filter)
+		if err != nil {
+			return err
+		}
+
+		return render(operations, func(w io.Writer) {
+			printHistory(w, operations)
+		})
+	}
+
+	return command
+}
+
+// parseSince accepts either an absolute time or a duration into the past.
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is neither an RFC3339 time nor a duration", since)
+	}
+
+	return time.Now().Add(-d), nil
+}
+
+func printHistory(w io.Writer, operations []storage.Operation) {
+	fmt.Fprintln(w, "time\tuser\toperation\tname\tfrom\tto\toutcome")
+	for _, operation := range operations {
+		from, to := operation.PreviousCommit, operation.Commit
+		if operation.PreviousVersion != nil {
+			from = operation.PreviousVersion.String()
+		}
+		if operation.Version != nil {
+			to = operation.Version.String()
+		}
+
+		outcome := string(operation.Outcome)
+		switch {
+		case operation.Error != "":
+			outcome = fmt.Sprintf("%s (%s)", outcome, operation.Error)
+		case operation.Reason != "":
+			outcome = fmt.Sprintf("%s (%s)", outcome, operation.Reason)
+		case operation.ForcePushed:
+			outcome = fmt.Sprintf("%s (force-pushed)", outcome)
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			operation.StartTime.Format(time.RFC3339),
+			operation.User,
+			operation.Type,
+			operation.Name,
+			from,
+			to,
+			outcome,
+		)
+	}
+}
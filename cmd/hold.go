@@ -0,0 +1,62 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func hold(fs afero.Fs) *cobra.Command {
+	vm := ""
+	constraint := ""
+	command := &cobra.Command{
+		Use: "hold",
+		Short: "Stops a virtual machine from being upgraded, or only upgrades " +
+			"it to versions matching a constraint.",
+	}
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to hold")
+	command.PersistentFlags().StringVar(&constraint, "constraint", "", "only upgrade to versions matching this constraint (e.g. \"~1.4\" or \"<2.0.0\") instead of not upgrading at all")
+	err := command.MarkPersistentFlagRequired("vm")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		return renderResult(apm.Hold(vm, constraint))
+	}
+
+	return command
+}
+
+func unhold(fs afero.Fs) *cobra.Command {
+	vm := ""
+	command := &cobra.Command{
+		Use:   "unhold",
+		Short: "Lets a held virtual machine be upgraded to any version again.",
+	}
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to unhold")
+	err := command.MarkPersistentFlagRequired("vm")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		return renderResult(apm.Unhold(vm))
+	}
+
+	return command
+}
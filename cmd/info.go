@@ -0,0 +1,73 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func info(fs afero.Fs) *cobra.Command {
+	vm := ""
+	command := &cobra.Command{
+		Annotations: map[string]string{
+			readOnlyAnnotation: "true",
+		},
+		Use:   "info",
+		Short: "Describes a virtual machine and whether it's installed",
+	}
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to describe")
+	err := command.MarkPersistentFlagRequired("vm")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		info, err := apm.Info(vm)
+		if err != nil {
+			return err
+		}
+
+		return render(info, func(w io.Writer) {
+			installed := "not installed"
+			if info.InstalledVersion != nil {
+				installed = info.InstalledVersion.String()
+			}
+			switch {
+			case info.Held:
+				installed += " (held)"
+			case info.Constraint != "":
+				installed += fmt.Sprintf(" (constrained to %s)", info.Constraint)
+			}
+
+			fmt.Fprintf(w, "name:\t%s\n", info.Name)
+			fmt.Fprintf(w, "id:\t%s\n", info.ID)
+			fmt.Fprintf(w, "description:\t%s\n", info.Description)
+			fmt.Fprintf(w, "homepage:\t%s\n", info.Homepage)
+			fmt.Fprintf(w, "version:\t%s\n", info.Version.String())
+			fmt.Fprintf(w, "commit:\t%s\n", info.Commit)
+			fmt.Fprintf(w, "installed:\t%s\n", installed)
+			if info.RPCChainVMProtocol != 0 {
+				fmt.Fprintf(w, "rpcchainvm protocol:\t%d\n", info.RPCChainVMProtocol)
+			}
+			if info.MinNodeVersion != nil {
+				fmt.Fprintf(w, "min node version:\t%s\n", info.MinNodeVersion)
+			}
+			if info.MaxNodeVersion != nil {
+				fmt.Fprintf(w, "max node version:\t%s\n", info.MaxNodeVersion)
+			}
+		})
+	}
+
+	return command
+}
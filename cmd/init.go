@@ -0,0 +1,165 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/utils/perms"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/shubhamdubey02/apm/node"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var (
+	errAlreadyInitialized    = errors.New("already initialized")
+	errUnsupportedConfigType = errors.New("unsupported config file type")
+)
+
+func initCommand(fs afero.Fs) *cobra.Command {
+	force := false
+	command := &cobra.Command{
+		Annotations: map[string]string{
+			// init adds profiles to the config file
+			newProfileAnnotation: "true",
+		},
+		Use: "init",
+		Short: "Writes an apm config file with the plugin path, admin api " +
+			"endpoint and node configs dir of a node, read from its config.",
+	}
+	command.PersistentFlags().BoolVar(&force, "force", false, "replace the settings if the config file already has them")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		log, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		settings, ok, err := readNodeSettings(fs)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("--%s is required", nodeConfigKey)
+		}
+
+		nodeConfig, err := filepath.Abs(os.ExpandEnv(viper.GetString(nodeConfigKey)))
+		if err != nil {
+			return err
+		}
+		values := map[string]interface{}{
+			nodeConfigKey:       nodeConfig,
+			adminAPIEndpointKey: settings.AdminAPIEndpoint(),
+		}
+		if settings.PluginDir != "" {
+			values[pluginPathKey] = settings.PluginDir
+		}
+		if settings.ConfigsDir != "" {
+			values[nodeConfigsDirKey] = settings.ConfigsDir
+		}
+		if !settings.AdminAPIEnabled {
+			if viper.GetBool(loadVMsKey) {
+				return requireAdminAPI(fs)
+			}
+			values[loadVMsKey] = false
+		}
+
+		path := viper.ConfigFileUsed()
+		if path == "" {
+			path = defaultConfigFile()
+		}
+		if err := writeConfig(fs, path, viper.GetString(profileKey), values, force); err != nil {
+			return err
+		}
+
+		log.Info("Wrote %s.", path)
+		if settings.PluginDir == "" {
+			log.Warn("The node config doesn't set %s or %s, so --%s must still be given.", node.PluginDirKey, node.BuildDirKey, pluginPathKey)
+		}
+		if settings.ConfigsDir == "" {
+			log.Warn("The node's %s and %s aren't in one directory, so --%s must still be given to write subnet configs.", node.ChainConfigDirKey, node.SubnetConfigDirKey, nodeConfigsDirKey)
+		}
+
+		return nil
+	}
+
+	return command
+}
+
+// writeConfig sets [values] in the config file at [path], or in [profile]
+// in it unless it's the default profile. Other settings are kept. The file is
+// written as json or yaml, going by its extension like viper does.
+func writeConfig(fs afero.Fs, path string, profile string, values map[string]interface{}, force bool) error {
+	marshal, err := configMarshaler(path)
+	if err != nil {
+		return err
+	}
+
+	config := map[string]interface{}{}
+	bytes, err := afero.ReadFile(fs, path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(bytes, &config); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	section := config
+	if profile != "" && profile != storage.DefaultProfile {
+		profiles, _ := config[profilesKey].(map[string]interface{})
+		if profiles == nil {
+			profiles = map[string]interface{}{}
+			config[profilesKey] = profiles
+		}
+		section, _ = profiles[profile].(map[string]interface{})
+		if section == nil {
+			section = map[string]interface{}{}
+			profiles[profile] = section
+		}
+	}
+
+	if _, ok := section[nodeConfigKey]; ok && !force {
+		return fmt.Errorf("%w: %s already has a %s. Pass --force to replace it", errAlreadyInitialized, path, nodeConfigKey)
+	}
+	for key, value := range values {
+		section[key] = value
+	}
+
+	bytes, err = marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), perms.ReadWriteExecute); err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, path, bytes, perms.ReadWrite)
+}
+
+// configMarshaler returns how the config file at [path] is encoded. Json is
+// valid yaml, so either is read with yaml.
+func configMarshaler(path string) (func(interface{}) ([]byte, error), error) {
+	switch ext := strings.TrimPrefix(filepath.Ext(path), "."); ext {
+	case "json":
+		return func(value interface{}) ([]byte, error) {
+			bytes, err := json.MarshalIndent(value, "", "  ")
+			return append(bytes, '\n'), err
+		}, nil
+	case "", "yaml", "yml":
+		return yaml.Marshal, nil
+	default:
+		return nil, fmt.Errorf("%w: can't write %s files to %s", errUnsupportedConfigType, ext, path)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteConfig(t *testing.T) {
+	const yamlPath = "/apm/config.yaml"
+	values := map[string]interface{}{
+		nodeConfigKey: "/node/config.json",
+		pluginPathKey: "/plugins",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		existing string
+		profile  string
+		force    bool
+		want     string
+		wantErr  error
+	}{
+		{
+			name:    "new file",
+			path:    yamlPath,
+			profile: "default",
+			want: "node-config: /node/config.json\n" +
+				"plugin-path: /plugins\n",
+		},
+		{
+			name:     "new profile keeps other settings",
+			path:     yamlPath,
+			existing: "log-level: debug\n",
+			profile:  "testnet",
+			want: "log-level: debug\n" +
+				"profiles:\n" +
+				"    testnet:\n" +
+				"        node-config: /node/config.json\n" +
+				"        plugin-path: /plugins\n",
+		},
+		{
+			name:     "already initialized",
+			path:     yamlPath,
+			existing: "node-config: /other/config.json\n",
+			profile:  "default",
+			wantErr:  errAlreadyInitialized,
+		},
+		{
+			name:     "forced",
+			path:     yamlPath,
+			existing: "node-config: /other/config.json\n",
+			profile:  "default",
+			force:    true,
+			want: "node-config: /node/config.json\n" +
+				"plugin-path: /plugins\n",
+		},
+		{
+			name:    "new json file",
+			path:    "/apm/config.json",
+			profile: "default",
+			want: "{\n" +
+				"  \"node-config\": \"/node/config.json\",\n" +
+				"  \"plugin-path\": \"/plugins\"\n" +
+				"}\n",
+		},
+		{
+			name:     "json stays json",
+			path:     "/apm/config.json",
+			existing: `{"log-level": "debug"}`,
+			profile:  "testnet",
+			want: "{\n" +
+				"  \"log-level\": \"debug\",\n" +
+				"  \"profiles\": {\n" +
+				"    \"testnet\": {\n" +
+				"      \"node-config\": \"/node/config.json\",\n" +
+				"      \"plugin-path\": \"/plugins\"\n" +
+				"    }\n" +
+				"  }\n" +
+				"}\n",
+		},
+		{
+			name:    "unsupported type",
+			path:    "/apm/config.toml",
+			profile: "default",
+			wantErr: errUnsupportedConfigType,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.existing != "" {
+				assert.NoError(t, afero.WriteFile(fs, test.path, []byte(test.existing), 0o600))
+			}
+
+			err := writeConfig(fs, test.path, test.profile, values, test.force)
+			assert.ErrorIs(t, err, test.wantErr)
+			if test.wantErr != nil {
+				return
+			}
+
+			bytes, err := afero.ReadFile(fs, test.path)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, string(bytes))
+		})
+	}
+}
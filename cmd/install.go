@@ -10,23 +10,30 @@ import (
 
 func install(fs afero.Fs) *cobra.Command {
 	vm := ""
+	force := false
 	command := &cobra.Command{
 		Use:   "install-vm",
 		Short: "Installs a virtual machine by its alias",
 	}
 	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to install")
+	command.PersistentFlags().BoolVar(&force, "force", false, "install even if the vm isn't compatible with the node")
 	err := command.MarkPersistentFlagRequired("vm")
 	if err != nil {
 		panic(err)
 	}
 
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		apm, err := initAPM(fs)
+		if err := requireAdminAPI(fs); err != nil {
+			return err
+		}
+
+		apm, err := initAPM(fs, forceOptions(force)...)
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
 
-		return apm.Install(vm)
+		return renderResult(apm.Install(vm))
 	}
 
 	return command
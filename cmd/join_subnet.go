@@ -4,32 +4,73 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+
+	"github.com/shubhamdubey02/apm/apm"
 )
 
 func joinSubnet(fs afero.Fs) *cobra.Command {
 	subnet := ""
+	force := false
+	yes := false
 
 	command := &cobra.Command{
 		Use:   "join-subnet",
-		Short: "Installs all virtual machines for a subnet.",
+		Short: "Installs all virtual machines for a subnet, and tracks it in the node config.",
 	}
 
 	command.PersistentFlags().StringVar(&subnet, "subnet", "", "subnet alias to join")
+	command.PersistentFlags().BoolVar(&force, "force", false, "install the subnet's vms even if they aren't compatible with the node")
+	command.PersistentFlags().BoolVarP(&yes, "yes", "y", false, "overwrite the node's subnet and chain configs without asking for confirmation")
 	err := command.MarkPersistentFlagRequired("subnet")
 	if err != nil {
 		panic(err)
 	}
 
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		apm, err := initAPM(fs)
+		if err := requireAdminAPI(fs); err != nil {
+			return err
+		}
+
+		apm, err := initAPM(fs, forceOptions(force)...)
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
+
+		if !yes {
+			if err := confirmConfigChanges(apm, subnet); err != nil {
+				return err
+			}
+		}
 
-		return apm.JoinSubnet(subnet)
+		return renderResult(apm.JoinSubnet(subnet))
 	}
 
 	return command
 }
+
+// confirmConfigChanges asks before joining [subnet] if it would overwrite any
+// of the node's existing configs, showing how they'd change.
+func confirmConfigChanges(a *apm.APM, subnet string) error {
+	changes, err := a.SubnetConfigChanges(subnet)
+	if err != nil {
+		return err
+	}
+
+	diffs := []string{}
+	for _, change := range changes {
+		if !change.Created && change.Changed() {
+			diffs = append(diffs, change.Diff)
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	return confirm(fmt.Sprintf("This will overwrite the node's configs, backing up the previous versions:\n%s\nContinue?", strings.Join(diffs, "\n")))
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func leaveSubnet(fs afero.Fs) *cobra.Command {
+	subnet := ""
+
+	command := &cobra.Command{
+		Use: "leave-subnet",
+		Short: "Stops tracking a joined subnet, and uninstalls the virtual " +
+			"machines that were only installed for it.",
+	}
+
+	command.PersistentFlags().StringVar(&subnet, "subnet", "", "subnet alias to leave")
+	err := command.MarkPersistentFlagRequired("subnet")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		return renderResult(apm.LeaveSubnet(subnet))
+	}
+
+	return command
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func listInstalled(fs afero.Fs) *cobra.Command {
+	allProfiles := false
+	command := &cobra.Command{
+		Annotations: map[string]string{
+			readOnlyAnnotation: "true",
+		},
+		Use:   "list-installed",
+		Short: "Lists the installed virtual machines",
+	}
+	command.PersistentFlags().BoolVar(&allProfiles, "all-profiles", false, "list the virtual machines of every profile in the config file")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		var selected []string
+		if allProfiles {
+			selected = profiles()
+		}
+
+		vms, err := apm.ListInstalled(selected...)
+		if err != nil {
+			return err
+		}
+
+		return render(vms, func(w io.Writer) {
+			fmt.Fprintln(w, "profile\tname\tversion\tinstalled for")
+			for _, vm := range vms {
+				version := vm.Version.String()
+				switch {
+				case vm.Held:
+					version += " (held)"
+				case vm.Constraint != "":
+					version += fmt.Sprintf(" (constrained to %s)", vm.Constraint)
+				}
+
+				reason := "explicit"
+				switch {
+				case vm.Automatic && len(vm.Subnets) > 0:
+					reason = strings.Join(vm.Subnets, ", ")
+				case vm.Automatic:
+					reason = "left subnets"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", vm.Profile, vm.Name, version, reason)
+			}
+		})
+	}
+
+	return command
+}
@@ -4,12 +4,18 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
 func listRepositories(fs afero.Fs) *cobra.Command {
 	command := &cobra.Command{
+		Annotations: map[string]string{
+			readOnlyAnnotation: "true",
+		},
 		Use:   "list-repositories",
 		Short: "Lists all tracked plugin repositories.",
 	}
@@ -18,9 +24,39 @@ func listRepositories(fs afero.Fs) *cobra.Command {
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
+
+		repositories, err := apm.ListRepositories()
+		if err != nil {
+			return err
+		}
 
-		return apm.ListRepositories()
+		return render(repositories, func(w io.Writer) {
+			fmt.Fprintln(w, "alias\turl\tref\tsize")
+			for _, repository := range repositories {
+				ref := repository.Ref
+				if repository.Tag != "" {
+					ref = fmt.Sprintf("%s (%s)", ref, repository.Tag)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", repository.Alias, repository.URL, ref, formatBytes(repository.DiskUsage))
+			}
+		})
 	}
 
 	return command
 }
+
+// formatBytes formats a size with a binary unit, e.g. 1.5 MiB.
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
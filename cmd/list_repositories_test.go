@@ -0,0 +1,40 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size uint64
+		want string
+	}{
+		{
+			size: 0,
+			want: "0 B",
+		},
+		{
+			size: 1023,
+			want: "1023 B",
+		},
+		{
+			size: 1024,
+			want: "1.0 KiB",
+		},
+		{
+			size: 1024*1024 + 512*1024,
+			want: "1.5 MiB",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, formatBytes(test.size))
+		})
+	}
+}
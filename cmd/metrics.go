@@ -0,0 +1,59 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/metrics"
+)
+
+// readOnlyAnnotation marks commands that don't change the apm's state, so
+// they don't write the metrics file.
+const readOnlyAnnotation = "read-only"
+
+// metricsRegistry is set if metrics were requested, either by a flag or by a
+// command that exposes them.
+var metricsRegistry *prometheus.Registry
+
+// metricsOptions returns the apm options needed to report metrics, if any
+// were requested.
+func metricsOptions() []apm.Option {
+	if metricsRegistry == nil && viper.GetString(metricsFileKey) != "" {
+		metricsRegistry = prometheus.NewRegistry()
+	}
+	if metricsRegistry == nil {
+		return nil
+	}
+
+	return []apm.Option{apm.WithMetrics(metricsRegistry)}
+}
+
+// writeMetricsFile makes [command] update the metrics file for the
+// node_exporter textfile collector once it's done, whether or not it
+// succeeded. Read-only commands leave it alone.
+func writeMetricsFile(command *cobra.Command) {
+	run := command.RunE
+	if run == nil || command.Annotations[readOnlyAnnotation] != "" {
+		return
+	}
+
+	command.RunE = func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+
+		path := viper.GetString(metricsFileKey)
+		if metricsRegistry == nil || path == "" {
+			return err
+		}
+
+		if writeErr := metrics.WriteTextfile(path, metricsRegistry); writeErr != nil && err == nil {
+			return writeErr
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/shubhamdubey02/apm/node"
+)
+
+var errAdminAPIDisabled = errors.New("the node's admin api is disabled")
+
+// readNodeSettings reads the settings of the node config given with
+// --node-config. It returns false if none was given.
+func readNodeSettings(fs afero.Fs) (node.Settings, bool, error) {
+	path := os.ExpandEnv(viper.GetString(nodeConfigKey))
+	if path == "" {
+		return node.Settings{}, false, nil
+	}
+
+	settings, err := node.NewConfig(fs, path).Settings()
+	return settings, true, err
+}
+
+// applyNodeSettings defaults the settings that the node config says, unless
+// they're set by a flag, the config file or the profile.
+func applyNodeSettings(fs afero.Fs, flags *pflag.FlagSet) error {
+	settings, ok, err := readNodeSettings(fs)
+	if err != nil || !ok {
+		return err
+	}
+
+	for key, value := range map[string]string{
+		pluginPathKey:       settings.PluginDir,
+		adminAPIEndpointKey: settings.AdminAPIEndpoint(),
+		nodeConfigsDirKey:   settings.ConfigsDir,
+	} {
+		if value != "" && !explicitlySet(flags, key) {
+			viper.Set(key, value)
+		}
+	}
+
+	return nil
+}
+
+// explicitlySet returns true if [key] was given on the command line, in the
+// config file or in the chosen profile.
+func explicitlySet(flags *pflag.FlagSet, key string) bool {
+	return flags.Changed(key) ||
+		viper.InConfig(key) ||
+		viper.IsSet(profilesKey+"."+viper.GetString(profileKey)+"."+key)
+}
+
+// requireAdminAPI fails fast if the node config says the admin api, which
+// installed vms are loaded with, is disabled.
+func requireAdminAPI(fs afero.Fs) error {
+	if !viper.GetBool(loadVMsKey) {
+		return nil
+	}
+
+	settings, ok, err := readNodeSettings(fs)
+	if err != nil || !ok || settings.AdminAPIEnabled {
+		return err
+	}
+
+	return fmt.Errorf(
+		"%w, so it can't load virtual machines. Set \"%s\": true in %s and restart the node, or pass --%s=false to load them when the node restarts",
+		errAdminAPIDisabled,
+		node.AdminAPIEnabledKey,
+		viper.GetString(nodeConfigKey),
+		loadVMsKey,
+	)
+}
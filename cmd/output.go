@@ -0,0 +1,72 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/shubhamdubey02/apm/apm"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+)
+
+func parseOutput(output string) (string, error) {
+	switch output {
+	case tableOutput, jsonOutput, yamlOutput:
+		return output, nil
+	default:
+		return "", fmt.Errorf("unknown output format %s (must be one of %s, %s, or %s)", output, tableOutput, jsonOutput, yamlOutput)
+	}
+}
+
+// machineReadable returns true if stdout is reserved for structured output,
+// in which case logs are written to stderr.
+func machineReadable() bool {
+	return viper.GetString(outputKey) != tableOutput
+}
+
+// render writes [value] to stdout in the requested output format. [table] is
+// used for the table format, and may be nil if there's nothing to show.
+func render(value interface{}, table func(w io.Writer)) error {
+	switch viper.GetString(outputKey) {
+	case jsonOutput:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case yamlOutput:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(value)
+	default:
+		if table == nil {
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+}
+
+// renderResult writes the result of a command that modified the apm's state.
+// The result is rendered even if the command failed, so automation can see
+// what happened before the failure.
+func renderResult(result apm.Result, err error) error {
+	if renderErr := render(result, nil); renderErr != nil && err == nil {
+		return renderErr
+	}
+
+	return err
+}
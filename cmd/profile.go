@@ -0,0 +1,126 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+const (
+	profilesKey = "profiles"
+	// upgradePolicyKey holds the auto-upgrade flags a profile sets, like
+	// upgrade-policy.level.
+	upgradePolicyKey = "upgrade-policy"
+)
+
+// newProfileAnnotation marks commands that may be given a profile that
+// isn't in the config file yet.
+const newProfileAnnotation = "new-profile"
+
+var errUnknownProfile = errors.New("unknown profile")
+
+// profileKeys are the settings a profile can override.
+var profileKeys = map[string]bool{
+	pluginPathKey:       true,
+	adminAPIEndpointKey: true,
+	adminAPICAFileKey:   true,
+	adminAPICertFileKey: true,
+	adminAPIKeyFileKey:  true,
+	adminAPIInsecureKey: true,
+	adminAPIHeaderKey:   true,
+	adminAPITokenKey:    true,
+	adminAPITimeoutKey:  true,
+	infoAPIEndpointKey:  true,
+	nodeConfigKey:       true,
+	nodeConfigsDirKey:   true,
+	loadVMsKey:          true,
+}
+
+// applyProfile overrides the settings of the config file with the ones of
+// the chosen profile. Flags given on the command line still win.
+func applyProfile(flags *pflag.FlagSet) error {
+	profile := viper.GetString(profileKey)
+	if profile == "" || (profile == storage.DefaultProfile && !viper.IsSet(profilesKey+"."+profile)) {
+		return nil
+	}
+
+	settings := viper.Sub(profilesKey + "." + profile)
+	if settings == nil {
+		return fmt.Errorf("%w %s (configured profiles: %s)", errUnknownProfile, profile, strings.Join(profiles(), ", "))
+	}
+
+	for _, key := range settings.AllKeys() {
+		switch {
+		case strings.HasPrefix(key, upgradePolicyKey+"."):
+			// read by auto-upgrade
+		case profileKeys[key]:
+			if flags.Changed(key) {
+				continue
+			}
+		default:
+			return fmt.Errorf("unknown setting %s in profile %s", key, profile)
+		}
+
+		viper.Set(key, settings.Get(key))
+	}
+
+	return nil
+}
+
+// profiles returns the default profile and the ones in the config file.
+func profiles() []string {
+	configured := viper.GetStringMap(profilesKey)
+
+	result := make([]string, 0, len(configured)+1)
+	result = append(result, storage.DefaultProfile)
+	for profile := range configured {
+		if profile != storage.DefaultProfile {
+			result = append(result, profile)
+		}
+	}
+	sort.Strings(result[1:])
+
+	return result
+}
+
+// applyUpgradePolicy sets the auto-upgrade [flags] that weren't given on the
+// command line from the upgrade policy in the config file or profile.
+func applyUpgradePolicy(flags *pflag.FlagSet) error {
+	names := []string{}
+	for _, key := range viper.AllKeys() {
+		if name := strings.TrimPrefix(key, upgradePolicyKey+"."); name != key {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown setting %s in %s", name, upgradePolicyKey)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		key := upgradePolicyKey + "." + name
+		value := viper.GetString(key)
+		if flag.Value.Type() == "stringSlice" {
+			value = strings.Join(viper.GetStringSlice(key), ",")
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+plugin-path: /plugins
+upgrade-policy:
+  level: patch
+profiles:
+  testnet:
+    plugin-path: /testnet/plugins
+    admin-api-endpoint: 127.0.0.1:9652/ext/admin
+    upgrade-policy:
+      exclude: [spacesvm, timestampvm]
+  typo:
+    plugin-dir: /plugins
+`
+
+func TestApplyProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     string
+		flags       []string
+		wantErr     error
+		wantPlugins string
+		wantAdmin   string
+	}{
+		{
+			name:        "default",
+			profile:     "default",
+			wantPlugins: "/plugins",
+			wantAdmin:   "",
+		},
+		{
+			name:        "profile overrides config",
+			profile:     "testnet",
+			wantPlugins: "/testnet/plugins",
+			wantAdmin:   "127.0.0.1:9652/ext/admin",
+		},
+		{
+			name:        "flag overrides profile",
+			profile:     "testnet",
+			flags:       []string{"--plugin-path=/flag/plugins"},
+			wantPlugins: "/flag/plugins",
+			wantAdmin:   "127.0.0.1:9652/ext/admin",
+		},
+		{
+			name:    "unknown profile",
+			profile: "mainnet",
+			wantErr: errUnknownProfile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			viper.SetConfigType("yaml")
+			assert.NoError(t, viper.ReadConfig(strings.NewReader(testConfig)))
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.String(pluginPathKey, "", "")
+			assert.NoError(t, flags.Parse(test.flags))
+			assert.NoError(t, viper.BindPFlag(pluginPathKey, flags.Lookup(pluginPathKey)))
+			viper.Set(profileKey, test.profile)
+
+			err := applyProfile(flags)
+			assert.ErrorIs(t, err, test.wantErr)
+			if test.wantErr != nil {
+				return
+			}
+			assert.Equal(t, test.wantPlugins, viper.GetString(pluginPathKey))
+			assert.Equal(t, test.wantAdmin, viper.GetString(adminAPIEndpointKey))
+		})
+	}
+}
+
+func TestApplyProfileUnknownSetting(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigType("yaml")
+	assert.NoError(t, viper.ReadConfig(strings.NewReader(testConfig)))
+	viper.Set(profileKey, "typo")
+
+	assert.ErrorContains(t, applyProfile(pflag.NewFlagSet("test", pflag.ContinueOnError)), "unknown setting plugin-dir")
+}
+
+func TestApplyUpgradePolicy(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigType("yaml")
+	assert.NoError(t, viper.ReadConfig(strings.NewReader(testConfig)))
+	viper.Set(profileKey, "testnet")
+	assert.NoError(t, applyProfile(pflag.NewFlagSet("test", pflag.ContinueOnError)))
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	level := flags.String("level", "minor", "")
+	exclude := flags.StringSlice("exclude", nil, "")
+	assert.NoError(t, applyUpgradePolicy(flags))
+
+	assert.Equal(t, "patch", *level)
+	assert.Equal(t, []string{"spacesvm", "timestampvm"}, *exclude)
+}
+
+func TestProfiles(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.SetConfigType("yaml")
+	assert.NoError(t, viper.ReadConfig(strings.NewReader(testConfig)))
+
+	assert.Equal(t, []string{"default", "testnet", "typo"}, profiles())
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	errNotConfirmed = errors.New("not confirmed")
+	errNotATerminal = errors.New("can't ask for confirmation without a terminal. Pass --yes to confirm")
+)
+
+// confirm asks the user a yes/no [question] on stdin, and returns
+// errNotConfirmed unless they answer yes.
+func confirm(question string) error {
+	if !isTerminal() {
+		return errNotATerminal
+	}
+
+	// stdout may be reserved for structured output
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errNotConfirmed
+	}
+}
+
+func isTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var errRefRequired = errors.New("exactly one of --branch, --tag, --commit or --latest-tag is required")
+
+// refFlags choose what a repository tracks.
+type refFlags struct {
+	branch    string
+	tag       string
+	commit    string
+	latestTag bool
+}
+
+func (r *refFlags) add(command *cobra.Command) {
+	command.PersistentFlags().StringVar(&r.branch, "branch", "", "branch name to track")
+	command.PersistentFlags().StringVar(&r.tag, "tag", "", "tag to pin the repository to")
+	command.PersistentFlags().StringVar(&r.commit, "commit", "", "commit hash to pin the repository to")
+	command.PersistentFlags().BoolVar(&r.latestTag, "latest-tag", false, "track the tag with the highest semantic version")
+}
+
+func (r *refFlags) ref() (storage.Ref, error) {
+	var refs []storage.Ref
+	if r.branch != "" {
+		refs = append(refs, storage.NewBranchRef(r.branch))
+	}
+	if r.tag != "" {
+		refs = append(refs, storage.NewTagRef(r.tag))
+	}
+	if r.commit != "" {
+		refs = append(refs, storage.NewCommitRef(r.commit))
+	}
+	if r.latestTag {
+		refs = append(refs, storage.NewLatestTagRef())
+	}
+	if len(refs) != 1 {
+		return storage.Ref{}, errRefRequired
+	}
+
+	return refs[0], refs[0].Verify()
+}
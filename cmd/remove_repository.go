@@ -27,8 +27,9 @@ func removeRepository(fs afero.Fs) *cobra.Command {
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
 
-		return apm.RemoveRepository(alias)
+		return renderResult(apm.RemoveRepository(alias))
 	}
 
 	return command
@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func repository(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "repository",
+		Short: "Manages tracked repositories",
+	}
+	command.AddCommand(setRef(fs))
+
+	return command
+}
+
+func setRef(fs afero.Fs) *cobra.Command {
+	alias := ""
+	refs := &refFlags{}
+
+	command := &cobra.Command{
+		Use:   "set-ref",
+		Short: "Changes the branch, tag or commit a repository tracks",
+		Long: "Changes the branch, tag or commit a repository tracks. Pinning a " +
+			"repository to a tag or commit keeps its definitions at a reviewed " +
+			"snapshot. The repository is synced to the new ref on the next update.",
+	}
+	command.PersistentFlags().StringVar(&alias, "alias", "", "alias of the repository")
+	if err := command.MarkPersistentFlagRequired("alias"); err != nil {
+		panic(err)
+	}
+	refs.add(command)
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		ref, err := refs.ref()
+		if err != nil {
+			return err
+		}
+
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		return renderResult(apm.SetRef(alias, ref))
+	}
+
+	return command
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/server"
+)
+
+const (
+	socketFileName    = "apm.sock"
+	readHeaderTimeout = 10 * time.Second
+)
+
+func serve(fs afero.Fs) *cobra.Command {
+	listen := ""
+	command := &cobra.Command{
+		Use:   "serve",
+		Short: "Serves a local HTTP/JSON management api until interrupted",
+		Long: `Serves a local HTTP/JSON management api until interrupted.
+
+Modifications are made one at a time, and must be sent with a Content-Type
+of application/json. Their responses are newline-delimited JSON: a log line
+for each step as it happens, followed by the result.
+
+  POST /v1/install       {"vm": "<alias>"}
+  POST /v1/uninstall     {"vm": "<alias>"}
+  POST /v1/upgrade       {"vm": "<alias>"} (optional, upgrades everything if empty)
+  POST /v1/update
+  POST /v1/join-subnet   {"subnet": "<alias>"}
+  GET  /v1/repositories
+  GET  /v1/info?vm=<alias>
+  GET  /metrics`,
+	}
+	command.PersistentFlags().StringVar(
+		&listen,
+		"listen",
+		"",
+		fmt.Sprintf("unix://<path> of a socket or loopback host:port to listen on. Defaults to %s in the apm path", socketFileName),
+	)
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		log, err := initLogger()
+		if err != nil {
+			return err
+		}
+
+		metricsRegistry = prometheus.NewRegistry()
+		progress := logging.NewSwitch(log)
+
+		apm, err := newAPM(fs, progress)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		if listen == "" {
+			listen = fmt.Sprintf("unix://%s", filepath.Join(viper.GetString(apmPathKey), socketFileName))
+		}
+		listener, err := server.Listen(listen)
+		if err != nil {
+			return err
+		}
+
+		httpServer := &http.Server{
+			ReadHeaderTimeout: readHeaderTimeout,
+			Handler: server.New(server.Config{
+				APM:      apm,
+				Log:      log,
+				Progress: progress,
+				Gatherer: metricsRegistry,
+				Address:  listen,
+			}),
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		go func() {
+			<-ctx.Done()
+			log.Info("Shutting down...")
+			_ = httpServer.Shutdown(context.Background())
+		}()
+
+		log.Info("Serving the management api on %s", listen)
+		if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+
+		return nil
+	}
+
+	return command
+}
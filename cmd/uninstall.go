@@ -25,8 +25,9 @@ func uninstall(fs afero.Fs) *cobra.Command {
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
 
-		return apm.Uninstall(vm)
+		return renderResult(apm.Uninstall(vm))
 	}
 
 	return command
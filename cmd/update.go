@@ -18,8 +18,9 @@ func update(fs afero.Fs) *cobra.Command {
 		if err != nil {
 			return err
 		}
+		defer apm.Close()
 
-		return apm.Update()
+		return renderResult(apm.Update())
 	}
 
 	return command
@@ -4,8 +4,15 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/util"
+	"github.com/shubhamdubey02/apm/workflow"
 )
 
 func upgrade(fs afero.Fs) *cobra.Command {
@@ -16,15 +23,67 @@ func upgrade(fs afero.Fs) *cobra.Command {
 		Short: "Upgrades a virtual machine. If none is specified, all " +
 			"installed virtual machines are upgraded.",
 	}
+	level := ""
+	allowDowngrade := false
+	yes := false
+	force := false
 	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to install")
+	command.PersistentFlags().StringVar(&level, "level", "", "largest version change to upgrade to (patch, minor, or major). Any change is allowed by default")
+	command.PersistentFlags().BoolVar(&allowDowngrade, "allow-downgrade", false, "follow the repository's version even if it's older than the installed one")
+	command.PersistentFlags().BoolVarP(&yes, "yes", "y", false, "downgrade without asking for confirmation")
+	command.PersistentFlags().BoolVar(&force, "force", false, "upgrade even if the new version isn't compatible with the node")
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		apm, err := initAPM(fs)
+		var opts []apm.UpgradeOption
+		if level != "" {
+			maxLevel, err := workflow.ParseLevel(level)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, apm.MaxLevel(maxLevel))
+		}
+		if allowDowngrade {
+			opts = append(opts, apm.AllowDowngrade())
+		}
+
+		if err := requireAdminAPI(fs); err != nil {
+			return err
+		}
+
+		a, err := initAPM(fs, forceOptions(force)...)
 		if err != nil {
 			return err
 		}
+		defer a.Close()
+
+		if allowDowngrade && !yes {
+			if err := confirmDowngrades(a, vm); err != nil {
+				return err
+			}
+		}
 
-		return apm.Upgrade(vm)
+		return renderResult(a.Upgrade(vm, opts...))
 	}
 
 	return command
 }
+
+// confirmDowngrades asks before upgrading if it would downgrade any of the
+// vms matching [vm].
+func confirmDowngrades(a *apm.APM, vm string) error {
+	downgrades, err := a.Downgrades()
+	if err != nil {
+		return err
+	}
+
+	changes := []string{}
+	for _, info := range downgrades {
+		if vm == "" || util.MatchesVM(info.Name, vm) {
+			changes = append(changes, fmt.Sprintf("  %s: %s -> %s", info.Name, info.InstalledVersion, &info.Version))
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return confirm(fmt.Sprintf("This will downgrade:\n%s\nContinue?", strings.Join(changes, "\n")))
+}
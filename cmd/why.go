@@ -0,0 +1,58 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func why(fs afero.Fs) *cobra.Command {
+	vm := ""
+	command := &cobra.Command{
+		Annotations: map[string]string{
+			readOnlyAnnotation: "true",
+		},
+		Use:   "why",
+		Short: "Explains why a virtual machine is installed",
+	}
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to explain")
+	err := command.MarkPersistentFlagRequired("vm")
+	if err != nil {
+		panic(err)
+	}
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		apm, err := initAPM(fs)
+		if err != nil {
+			return err
+		}
+		defer apm.Close()
+
+		reason, err := apm.Why(vm)
+		if err != nil {
+			return err
+		}
+
+		return render(reason, func(w io.Writer) {
+			subnets := strings.Join(reason.Subnets, ", ")
+			switch {
+			case reason.Explicit && len(reason.Subnets) > 0:
+				fmt.Fprintf(w, "%s was installed explicitly, and is needed by %s.\n", reason.Name, subnets)
+			case reason.Explicit:
+				fmt.Fprintf(w, "%s was installed explicitly.\n", reason.Name)
+			case len(reason.Subnets) > 0:
+				fmt.Fprintf(w, "%s was installed for %s.\n", reason.Name, subnets)
+			default:
+				fmt.Fprintf(w, "%s was installed for subnets that were left. Run autoremove to uninstall it.\n", reason.Name)
+			}
+		})
+	}
+
+	return command
+}
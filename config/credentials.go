@@ -3,7 +3,246 @@
 
 package config
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix marks a secret that's read from an environment variable,
+	// e.g. env:GITHUB_TOKEN.
+	EnvPrefix = "env:"
+	// FilePrefix marks a secret that's read from a file, e.g.
+	// file:/run/secrets/github-token.
+	FilePrefix = "file:"
+
+	// UsernameEnv, PasswordEnv and TokenEnv set the default credential,
+	// taking precedence over the credentials file.
+	UsernameEnv = "APM_GIT_USERNAME"
+	PasswordEnv = "APM_GIT_PASSWORD"
+	TokenEnv    = "APM_GIT_TOKEN"
+)
+
+var (
+	ErrInsecurePermissions = errors.New("insecure permissions")
+	ErrMissingSecret       = errors.New("missing secret")
+)
+
+// Credential authenticates against a git remote. Only the fields that apply
+// to the remote's transport may be set.
 type Credential struct {
+	// Username and Password are sent as basic auth over https. Personal
+	// access tokens are usually given as the password.
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
+	// Token is sent as a bearer token over https.
+	Token string `yaml:"token"`
+	// SSHKey is the path to a private key used for ssh remotes. If it isn't
+	// set, the ssh agent is used.
+	SSHKey           string `yaml:"ssh-key"`
+	SSHKeyPassphrase string `yaml:"ssh-key-passphrase"`
+	// Helper asks git's credential helpers for the username and password of
+	// https remotes, like git credential fill does. The helpers are told
+	// whether the remote accepted them.
+	Helper bool `yaml:"helper"`
+}
+
+// Resolve reads the secrets that reference an environment variable or a file.
+func (c Credential) Resolve() (Credential, error) {
+	var err error
+	if c.Password, err = ResolveSecret(c.Password); err != nil {
+		return Credential{}, fmt.Errorf("failed to resolve password: %w", err)
+	}
+	if c.Token, err = ResolveSecret(c.Token); err != nil {
+		return Credential{}, fmt.Errorf("failed to resolve token: %w", err)
+	}
+	if c.SSHKeyPassphrase, err = ResolveSecret(c.SSHKeyPassphrase); err != nil {
+		return Credential{}, fmt.Errorf("failed to resolve ssh key passphrase: %w", err)
+	}
+	c.SSHKey = os.ExpandEnv(c.SSHKey)
+	return c, nil
+}
+
+// Credentials are the contents of the credentials file.
+type Credentials struct {
+	// Credential is used for repositories without a more specific match.
+	// Only its https fields are used for http(s) remotes and its ssh fields
+	// for ssh remotes. It isn't used for other remotes, like local ones.
+	Credential `yaml:",inline"`
+	// Repositories maps a host (github.com) or a repository
+	// (github.com/org/repo) to the credential used for it.
+	Repositories map[string]Credential `yaml:"repositories"`
+}
+
+// ReadCredentials reads the credentials file at [path] and resolves its
+// secrets. The file must only be accessible by its owner.
+func ReadCredentials(path string) (Credentials, error) {
+	bytes, err := readSecretFile(path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	result := Credentials{}
+	if err := yaml.Unmarshal(bytes, &result); err != nil {
+		return Credentials{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return result.Resolve()
+}
+
+// Resolve resolves the secrets of every credential.
+func (c Credentials) Resolve() (Credentials, error) {
+	var err error
+	if c.Credential, err = c.Credential.Resolve(); err != nil {
+		return Credentials{}, err
+	}
+
+	repositories := make(map[string]Credential, len(c.Repositories))
+	for prefix, credential := range c.Repositories {
+		if repositories[prefix], err = credential.Resolve(); err != nil {
+			return Credentials{}, fmt.Errorf("%s: %w", prefix, err)
+		}
+	}
+	c.Repositories = repositories
+
+	return c, nil
+}
+
+// WithEnv returns the credentials with the default credential overridden by
+// the environment variables that are set.
+func (c Credentials) WithEnv() Credentials {
+	if username, ok := os.LookupEnv(UsernameEnv); ok {
+		c.Username = username
+	}
+	if password, ok := os.LookupEnv(PasswordEnv); ok {
+		c.Password = password
+	}
+	if token, ok := os.LookupEnv(TokenEnv); ok {
+		c.Token = token
+	}
+	return c
+}
+
+// ResolveSecret returns the secret [value] refers to. Values without a
+// prefix are returned as is.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, EnvPrefix):
+		name := strings.TrimPrefix(value, EnvPrefix)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %s isn't set", ErrMissingSecret, name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, FilePrefix):
+		bytes, err := readSecretFile(os.ExpandEnv(strings.TrimPrefix(value, FilePrefix)))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(bytes), "\r\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// readSecretFile reads [path], refusing files that other users can access.
+func readSecretFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	// windows doesn't have unix permissions
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("%w: %s is accessible by other users (%s), run chmod 600 %s", ErrInsecurePermissions, path, info.Mode().Perm(), path)
+	}
+	return os.ReadFile(path)
+}
+
+// Lookup returns the credential for the repository at [url]. The longest
+// matching host or repository prefix wins.
+func (c Credentials) Lookup(url string) Credential {
+	location := NormalizeURL(url)
+
+	result := c.Credential.forRemote(url)
+	longest := -1
+	for prefix, credential := range c.Repositories {
+		prefix = NormalizeURL(prefix)
+		if len(prefix) <= longest {
+			continue
+		}
+		if location != prefix && !strings.HasPrefix(location, prefix+"/") {
+			continue
+		}
+		result = credential
+		longest = len(prefix)
+	}
+
+	return result
+}
+
+// NormalizeURL reduces a git remote to host/path, so that https, ssh and
+// scp-like remotes of the same repository compare equal.
+func NormalizeURL(url string) string {
+	location := url
+	if i := strings.Index(location, "://"); i >= 0 {
+		location = location[i+3:]
+	} else if i := strings.Index(location, ":"); i >= 0 && !strings.Contains(location[:i], "/") {
+		// scp-like syntax, e.g. git@github.com:org/repo.git
+		location = location[:i] + "/" + location[i+1:]
+	}
+	if i := strings.Index(location, "@"); i >= 0 && i < strings.Index(location+"/", "/") {
+		location = location[i+1:]
+	}
+
+	host, path, _ := strings.Cut(location, "/")
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		// drop the port
+		host = host[:i]
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	if path == "" {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(host) + "/" + path
+}
+
+// forRemote returns the fields of the credential that apply to the transport
+// of the remote at [url].
+func (c Credential) forRemote(url string) Credential {
+	switch remoteScheme(url) {
+	case "http", "https":
+		return Credential{
+			Username: c.Username,
+			Password: c.Password,
+			Token:    c.Token,
+			Helper:   c.Helper,
+		}
+	case "ssh":
+		// the username is left to the remote, since it's usually git
+		return Credential{
+			SSHKey:           c.SSHKey,
+			SSHKeyPassphrase: c.SSHKeyPassphrase,
+		}
+	default:
+		return Credential{}
+	}
+}
+
+// remoteScheme returns the scheme of the remote at [url]. Scp-like remotes
+// are ssh, and paths are file.
+func remoteScheme(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		return strings.ToLower(url[:i])
+	}
+	// a single letter before the colon is a windows drive
+	if i := strings.Index(url, ":"); i > 1 && !strings.Contains(url[:i], "/") {
+		return "ssh"
+	}
+	return "file"
 }
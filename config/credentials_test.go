@@ -0,0 +1,172 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/org/repo", want: "github.com/org/repo"},
+		{url: "https://user@GitHub.com:443/org/repo.git/", want: "github.com/org/repo"},
+		{url: "ssh://git@github.com:22/org/repo.git", want: "github.com/org/repo"},
+		{url: "git@github.com:org/repo.git", want: "github.com/org/repo"},
+		{url: "github.com", want: "github.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			assert.Equal(t, test.want, NormalizeURL(test.url))
+		})
+	}
+}
+
+func TestCredentialsLookup(t *testing.T) {
+	var (
+		fallback = Credential{Username: "user", Password: "password"}
+		host     = Credential{Token: "host"}
+		repo     = Credential{SSHKey: "/keys/id_ed25519"}
+	)
+	credentials := Credentials{
+		Credential: fallback,
+		Repositories: map[string]Credential{
+			"gitlab.example.com":             host,
+			"git@gitlab.example.com:org/vms": repo,
+		},
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want Credential
+	}{
+		{
+			name: "no match",
+			url:  "https://github.com/org/vms",
+			want: fallback,
+		},
+		{
+			name: "host",
+			url:  "https://gitlab.example.com/other/vms",
+			want: host,
+		},
+		{
+			name: "repository beats host",
+			url:  "ssh://git@gitlab.example.com/org/vms.git",
+			want: repo,
+		},
+		{
+			name: "prefix only matches whole path segments",
+			url:  "https://gitlab.example.com/org/vms-private",
+			want: host,
+		},
+		{
+			name: "fallback password isn't used for ssh",
+			url:  "git@github.com:org/vms.git",
+			want: Credential{},
+		},
+		{
+			name: "fallback isn't used for local remotes",
+			url:  "file:///srv/git/vms",
+			want: Credential{},
+		},
+		{
+			name: "fallback isn't used for paths",
+			url:  "/srv/git/vms",
+			want: Credential{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, credentials.Lookup(test.url))
+		})
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	dir := t.TempDir()
+	private := filepath.Join(dir, "private")
+	assert.NoError(t, os.WriteFile(private, []byte("from-file\n"), 0o600))
+	public := filepath.Join(dir, "public")
+	assert.NoError(t, os.WriteFile(public, []byte("from-file\n"), 0o644))
+	t.Setenv("APM_TEST_SECRET", "from-env")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "plain",
+			value: "plain",
+			want:  "plain",
+		},
+		{
+			name:  "env",
+			value: "env:APM_TEST_SECRET",
+			want:  "from-env",
+		},
+		{
+			name:    "missing env",
+			value:   "env:APM_TEST_MISSING",
+			wantErr: ErrMissingSecret,
+		},
+		{
+			name:  "file",
+			value: "file:" + private,
+			want:  "from-file",
+		},
+		{
+			name:    "readable by others",
+			value:   "file:" + public,
+			wantErr: ErrInsecurePermissions,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret, err := ResolveSecret(test.value)
+			assert.ErrorIs(t, err, test.wantErr)
+			assert.Equal(t, test.want, secret)
+		})
+	}
+}
+
+func TestReadCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	contents := []byte(`username: user
+password: env:APM_TEST_PASSWORD
+repositories:
+  gitlab.example.com:
+    helper: true
+`)
+	assert.NoError(t, os.WriteFile(path, contents, 0o600))
+	t.Setenv("APM_TEST_PASSWORD", "password")
+
+	credentials, err := ReadCredentials(path)
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{
+		Credential: Credential{Username: "user", Password: "password"},
+		Repositories: map[string]Credential{
+			"gitlab.example.com": {Helper: true},
+		},
+	}, credentials)
+
+	t.Setenv(TokenEnv, "token")
+	assert.Equal(t, "token", credentials.WithEnv().Token)
+
+	assert.NoError(t, os.Chmod(path, 0o644))
+	_, err = ReadCredentials(path)
+	assert.ErrorIs(t, err, ErrInsecurePermissions)
+}
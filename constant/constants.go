@@ -10,4 +10,7 @@ const (
 	CoreBranch             = "master"
 	QualifiedNameDelimiter = ":"
 	AliasDelimiter         = "/"
+	// VMsDir and SubnetsDir are where a repository's definitions are.
+	VMsDir     = "vms"
+	SubnetsDir = "subnets"
 )
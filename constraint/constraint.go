@@ -0,0 +1,163 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package constraint
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/version"
+)
+
+var errEmptyVersion = errors.New("empty version")
+
+// Constraint is a set of version ranges that must all be satisfied, like
+// ">=1.4.0, <2.0.0". The zero value allows every version.
+type Constraint struct {
+	raw    string
+	ranges []versionRange
+}
+
+// versionRange compares a version against [version] with [op].
+type versionRange struct {
+	op      string
+	version version.Semantic
+}
+
+// Parse parses a comma or space separated list of ranges. Each range is a
+// version prefixed by one of =, >, >=, <, <=, ~ or ^. Versions may omit the
+// minor and patch versions, and the leading v.
+//
+//   - ~1.4 allows >=1.4.0, <1.5.0
+//   - ~1 allows >=1.0.0, <2.0.0
+//   - ^1.4 allows >=1.4.0, <2.0.0
+//   - ^0.4 allows >=0.4.0, <0.5.0
+func Parse(constraint string) (Constraint, error) {
+	result := Constraint{
+		raw: strings.TrimSpace(constraint),
+	}
+
+	for _, field := range strings.FieldsFunc(constraint, func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		ranges, err := parseRange(field)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid constraint %s: %w", constraint, err)
+		}
+		result.ranges = append(result.ranges, ranges...)
+	}
+
+	return result, nil
+}
+
+func parseRange(field string) ([]versionRange, error) {
+	op := strings.TrimRight(field, "v0123456789.")
+	v, parts, err := parseVersion(strings.TrimPrefix(field, op))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "", "=":
+		if parts == 3 {
+			return []versionRange{{op: "=", version: v}}, nil
+		}
+		// a partial version matches everything it's a prefix of
+		return []versionRange{{op: ">=", version: v}, {op: "<", version: bump(v, parts)}}, nil
+	case ">", ">=", "<", "<=":
+		return []versionRange{{op: op, version: v}}, nil
+	case "~":
+		// patch changes, or minor changes if only the major version is given
+		if parts == 1 {
+			return []versionRange{{op: ">=", version: v}, {op: "<", version: bump(v, 1)}}, nil
+		}
+		return []versionRange{{op: ">=", version: v}, {op: "<", version: bump(v, 2)}}, nil
+	case "^":
+		// changes that don't modify the leftmost non-zero version
+		switch {
+		case v.Major != 0 || parts == 1:
+			return []versionRange{{op: ">=", version: v}, {op: "<", version: bump(v, 1)}}, nil
+		case v.Minor != 0 || parts == 2:
+			return []versionRange{{op: ">=", version: v}, {op: "<", version: bump(v, 2)}}, nil
+		default:
+			return []versionRange{{op: "=", version: v}}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// parseVersion parses a possibly partial version, and returns how many of
+// its parts were given.
+func parseVersion(s string) (version.Semantic, int, error) {
+	s = strings.TrimPrefix(s, "v")
+	if s == "" {
+		return version.Semantic{}, 0, errEmptyVersion
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version.Semantic{}, 0, fmt.Errorf("%s has too many parts", s)
+	}
+
+	numbers := [3]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version.Semantic{}, 0, fmt.Errorf("%s isn't a version", s)
+		}
+		numbers[i] = n
+	}
+
+	return version.Semantic{
+		Major: numbers[0],
+		Minor: numbers[1],
+		Patch: numbers[2],
+	}, len(parts), nil
+}
+
+// bump returns the smallest version that's larger than every version
+// starting with the first [parts] parts of [v].
+func bump(v version.Semantic, parts int) version.Semantic {
+	switch parts {
+	case 1:
+		return version.Semantic{Major: v.Major + 1}
+	case 2:
+		return version.Semantic{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return version.Semantic{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+// Allows returns true if [v] satisfies every range in the constraint.
+func (c Constraint) Allows(v *version.Semantic) bool {
+	for _, r := range c.ranges {
+		comparison := v.Compare(&r.version)
+
+		var ok bool
+		switch r.op {
+		case "=":
+			ok = comparison == 0
+		case ">":
+			ok = comparison > 0
+		case ">=":
+			ok = comparison >= 0
+		case "<":
+			ok = comparison < 0
+		case "<=":
+			ok = comparison <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package constraint
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []version.Semantic
+		disallowed []version.Semantic
+		wantErr    bool
+	}{
+		{
+			constraint: "",
+			allowed:    []version.Semantic{{Major: 0}, {Major: 9, Minor: 9, Patch: 9}},
+		},
+		{
+			constraint: "~1.4",
+			allowed:    []version.Semantic{{Major: 1, Minor: 4}, {Major: 1, Minor: 4, Patch: 9}},
+			disallowed: []version.Semantic{{Major: 1, Minor: 3, Patch: 9}, {Major: 1, Minor: 5}},
+		},
+		{
+			constraint: "~1",
+			allowed:    []version.Semantic{{Major: 1, Minor: 9}},
+			disallowed: []version.Semantic{{Major: 2}},
+		},
+		{
+			constraint: "^1.4",
+			allowed:    []version.Semantic{{Major: 1, Minor: 9}},
+			disallowed: []version.Semantic{{Major: 1, Minor: 3}, {Major: 2}},
+		},
+		{
+			constraint: "^0.4.1",
+			allowed:    []version.Semantic{{Major: 0, Minor: 4, Patch: 2}},
+			disallowed: []version.Semantic{{Major: 0, Minor: 5}},
+		},
+		{
+			constraint: "<2.0.0",
+			allowed:    []version.Semantic{{Major: 1, Minor: 99}},
+			disallowed: []version.Semantic{{Major: 2}},
+		},
+		{
+			constraint: ">=1.2, <1.8",
+			allowed:    []version.Semantic{{Major: 1, Minor: 2}, {Major: 1, Minor: 7, Patch: 9}},
+			disallowed: []version.Semantic{{Major: 1, Minor: 1}, {Major: 1, Minor: 8}},
+		},
+		{
+			constraint: "v1.2.3",
+			allowed:    []version.Semantic{{Major: 1, Minor: 2, Patch: 3}},
+			disallowed: []version.Semantic{{Major: 1, Minor: 2, Patch: 4}},
+		},
+		{
+			constraint: "1.2",
+			allowed:    []version.Semantic{{Major: 1, Minor: 2, Patch: 7}},
+			disallowed: []version.Semantic{{Major: 1, Minor: 3}},
+		},
+		{
+			constraint: "!1.2",
+			wantErr:    true,
+		},
+		{
+			constraint: ">=",
+			wantErr:    true,
+		},
+		{
+			constraint: "1.2.3.4",
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			c, err := Parse(test.constraint)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, v := range test.allowed {
+				v := v
+				assert.True(t, c.Allows(&v), "%s should allow %s", test.constraint, &v)
+			}
+			for _, v := range test.disallowed {
+				v := v
+				assert.False(t, c.Allows(&v), "%s shouldn't allow %s", test.constraint, &v)
+			}
+		})
+	}
+}
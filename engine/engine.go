@@ -3,16 +3,173 @@
 
 package engine
 
-import "github.com/shubhamdubey02/apm/workflow"
+import (
+	"time"
+
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
 
 var _ workflow.Executor = &WorkflowEngine{}
 
-func NewWorkflowEngine() *WorkflowEngine {
-	return &WorkflowEngine{}
+type Config struct {
+	// History is where executed operations are recorded. If nil, operations
+	// aren't persisted.
+	History storage.Storage[storage.Operation]
+	// User is recorded as the user who requested each operation.
+	User string
+	// Profile is recorded as the profile each operation acted on.
+	Profile string
+	// Observer, if set, is called with every operation the engine records and
+	// the error its workflow returned.
+	Observer func(storage.Operation, error)
+}
+
+func NewWorkflowEngine(config Config) *WorkflowEngine {
+	return &WorkflowEngine{
+		history:  config.History,
+		user:     config.User,
+		profile:  config.Profile,
+		observer: config.Observer,
+		now:      time.Now,
+	}
+}
+
+// WorkflowEngine executes workflows and records the ones that are
+// workflow.Recordable in the operation history.
+// Not safe for concurrent use.
+type WorkflowEngine struct {
+	history  storage.Storage[storage.Operation]
+	user     string
+	profile  string
+	observer func(storage.Operation, error)
+	now      func() time.Time
+
+	// ids of the recordable workflows currently executing, outermost first
+	running []uint64
+	lastID  uint64
+	// seeded is set once lastID has been read from the history
+	seeded bool
+}
+
+func (w *WorkflowEngine) Execute(wf workflow.Workflow) error {
+	recordable, ok := wf.(workflow.Recordable)
+	if !ok || (w.history == nil && w.observer == nil) {
+		return wf.Execute()
+	}
+
+	start := w.now()
+	id, err := w.nextID(start)
+	if err != nil {
+		return err
+	}
+
+	var parent uint64
+	if len(w.running) > 0 {
+		parent = w.running[len(w.running)-1]
+	}
+
+	w.running = append(w.running, id)
+	err = wf.Execute()
+	w.running = w.running[:len(w.running)-1]
+
+	operation := recordable.Operation()
+	operation.ID = id
+	operation.Parent = parent
+	operation.User = w.user
+	operation.Profile = w.profile
+	operation.StartTime = start
+	operation.EndTime = w.now()
+
+	if operation.Outcome == "" {
+		if err != nil {
+			operation.Outcome = storage.Failed
+		} else {
+			operation.Outcome = storage.Succeeded
+		}
+	}
+	if err != nil && operation.Outcome == storage.Failed {
+		operation.Error = err.Error()
+	}
+
+	if w.observer != nil {
+		w.observer(operation, err)
+	}
+	if w.history == nil {
+		return err
+	}
+	if putErr := w.put(operation); putErr != nil && err == nil {
+		return putErr
+	}
+
+	return err
+}
+
+// put appends [operation] to the history. If another apm process recorded an
+// operation with the same id while it ran, it's recorded under the next free
+// id instead of overwriting it.
+func (w *WorkflowEngine) put(operation storage.Operation) error {
+	taken, err := w.history.Has(storage.OperationKey(operation.ID))
+	if err != nil {
+		return err
+	}
+	if taken {
+		operation.ID, err = w.nextID(operation.StartTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.history.Put(storage.OperationKey(operation.ID), operation)
 }
 
-type WorkflowEngine struct{}
+// nextID returns a unique, increasing id for an operation started at [start].
+// Ids are based on the start time so they stay ordered across apm runs, and
+// never go below the last recorded id, even if the clock went backwards.
+func (w *WorkflowEngine) nextID(start time.Time) (uint64, error) {
+	if err := w.seed(); err != nil {
+		return 0, err
+	}
+
+	id := uint64(start.UnixNano())
+	if id <= w.lastID {
+		id = w.lastID + 1
+	}
+	for w.history != nil {
+		taken, err := w.history.Has(storage.OperationKey(id))
+		if err != nil {
+			return 0, err
+		}
+		if !taken {
+			break
+		}
+		id++
+	}
+	w.lastID = id
+
+	return id, nil
+}
+
+// seed reads the last recorded id from the history the first time an id is
+// needed.
+func (w *WorkflowEngine) seed() error {
+	if w.seeded || w.history == nil {
+		return nil
+	}
+
+	itr := w.history.Iterator()
+	defer itr.Release()
+
+	// keys sort by id, so the last one is the greatest
+	for itr.Next() {
+		if id := storage.OperationID(itr.Key()); id > w.lastID {
+			w.lastID = id
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
 
-func (w WorkflowEngine) Execute(workflow workflow.Workflow) error {
-	return workflow.Execute()
+	w.seeded = true
+	return nil
 }
@@ -0,0 +1,290 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package engine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/database/memdb"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+var (
+	_ workflow.Workflow   = workflowFunc(nil)
+	_ workflow.Recordable = &recordableWorkflow{}
+)
+
+type workflowFunc func() error
+
+func (w workflowFunc) Execute() error {
+	return w()
+}
+
+type recordableWorkflow struct {
+	execute   func() error
+	operation storage.Operation
+}
+
+func (r *recordableWorkflow) Execute() error {
+	return r.execute()
+}
+
+func (r *recordableWorkflow) Operation() storage.Operation {
+	return r.operation
+}
+
+func TestWorkflowEngineExecute(t *testing.T) {
+	errWrong := fmt.Errorf("something went wrong")
+	now := time.Unix(0, 100)
+
+	type mocks struct {
+		history *storage.MockStorage[storage.Operation]
+		engine  *WorkflowEngine
+		// db backs the history's reads
+		db database.Database
+	}
+	tests := []struct {
+		name string
+		// recorded are the ids already in the history
+		recorded []uint64
+		workflow func(mocks) workflow.Workflow
+		setup    func(mocks)
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "not recordable",
+			workflow: func(mocks) workflow.Workflow {
+				return workflowFunc(func() error { return errWrong })
+			},
+			setup: func(mocks) {},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, errWrong, err)
+			},
+		},
+		{
+			name: "success",
+			workflow: func(mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error { return nil },
+					operation: storage.Operation{
+						Type: storage.InstallOperation,
+						Name: "organization/repository:vm",
+					},
+				}
+			},
+			setup: func(mocks mocks) {
+				mocks.history.EXPECT().Put(storage.OperationKey(100), storage.Operation{
+					ID:        100,
+					Type:      storage.InstallOperation,
+					Name:      "organization/repository:vm",
+					User:      "user",
+					StartTime: now,
+					EndTime:   now,
+					Outcome:   storage.Succeeded,
+				}).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name: "failure is recorded",
+			workflow: func(mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error { return errWrong },
+					operation: storage.Operation{
+						Type: storage.InstallOperation,
+					},
+				}
+			},
+			setup: func(mocks mocks) {
+				mocks.history.EXPECT().Put(storage.OperationKey(100), storage.Operation{
+					ID:        100,
+					Type:      storage.InstallOperation,
+					User:      "user",
+					StartTime: now,
+					EndTime:   now,
+					Outcome:   storage.Failed,
+					Error:     errWrong.Error(),
+				}).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, errWrong, err)
+			},
+		},
+		{
+			name: "workflow outcome is kept",
+			workflow: func(mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error { return workflow.ErrAlreadyUpdated },
+					operation: storage.Operation{
+						Type:    storage.UpgradeOperation,
+						Outcome: storage.Skipped,
+					},
+				}
+			},
+			setup: func(mocks mocks) {
+				mocks.history.EXPECT().Put(storage.OperationKey(100), storage.Operation{
+					ID:        100,
+					Type:      storage.UpgradeOperation,
+					User:      "user",
+					StartTime: now,
+					EndTime:   now,
+					Outcome:   storage.Skipped,
+				}).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, workflow.ErrAlreadyUpdated, err)
+			},
+		},
+		{
+			name: "nested operations record their parent",
+			workflow: func(mocks mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error {
+						return mocks.engine.Execute(&recordableWorkflow{
+							execute: func() error { return nil },
+							operation: storage.Operation{
+								Type: storage.InstallOperation,
+							},
+						})
+					},
+					operation: storage.Operation{
+						Type: storage.JoinSubnetOperation,
+					},
+				}
+			},
+			setup: func(mocks mocks) {
+				gomock.InOrder(
+					mocks.history.EXPECT().Put(storage.OperationKey(101), storage.Operation{
+						ID:        101,
+						Parent:    100,
+						Type:      storage.InstallOperation,
+						User:      "user",
+						StartTime: now,
+						EndTime:   now,
+						Outcome:   storage.Succeeded,
+					}).Return(nil),
+					mocks.history.EXPECT().Put(storage.OperationKey(100), storage.Operation{
+						ID:        100,
+						Type:      storage.JoinSubnetOperation,
+						User:      "user",
+						StartTime: now,
+						EndTime:   now,
+						Outcome:   storage.Succeeded,
+					}).Return(nil),
+				)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name:     "ids continue after the last recorded operation",
+			recorded: []uint64{50, 200},
+			workflow: func(mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error { return nil },
+					operation: storage.Operation{
+						Type: storage.InstallOperation,
+					},
+				}
+			},
+			setup: func(mocks mocks) {
+				mocks.history.EXPECT().Put(storage.OperationKey(201), storage.Operation{
+					ID:        201,
+					Type:      storage.InstallOperation,
+					User:      "user",
+					StartTime: now,
+					EndTime:   now,
+					Outcome:   storage.Succeeded,
+				}).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name: "id recorded by another process isn't overwritten",
+			workflow: func(mocks mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error {
+						// another apm process records an operation meanwhile
+						return mocks.db.Put(storage.OperationKey(100), nil)
+					},
+					operation: storage.Operation{
+						Type: storage.InstallOperation,
+					},
+				}
+			},
+			setup: func(mocks mocks) {
+				mocks.history.EXPECT().Put(storage.OperationKey(101), storage.Operation{
+					ID:        101,
+					Type:      storage.InstallOperation,
+					User:      "user",
+					StartTime: now,
+					EndTime:   now,
+					Outcome:   storage.Succeeded,
+				}).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name: "can't write history",
+			workflow: func(mocks) workflow.Workflow {
+				return &recordableWorkflow{
+					execute: func() error { return nil },
+				}
+			},
+			setup: func(mocks mocks) {
+				mocks.history.EXPECT().Put(gomock.Any(), gomock.Any()).Return(errWrong)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, errWrong, err)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			var history *storage.MockStorage[storage.Operation]
+			history = storage.NewMockStorage[storage.Operation](ctrl)
+
+			db := memdb.New()
+			for _, id := range test.recorded {
+				assert.NoError(t, db.Put(storage.OperationKey(id), nil))
+			}
+			history.EXPECT().Has(gomock.Any()).DoAndReturn(db.Has).AnyTimes()
+			history.EXPECT().Iterator().DoAndReturn(func() storage.Iterator[storage.Operation] {
+				return *storage.NewIterator[storage.Operation](db.NewIterator())
+			}).AnyTimes()
+
+			engine := NewWorkflowEngine(Config{
+				History: history,
+				User:    "user",
+			})
+			engine.now = func() time.Time { return now }
+
+			mocks := mocks{
+				history: history,
+				engine:  engine,
+				db:      db,
+			}
+			test.setup(mocks)
+
+			test.wantErr(t, engine.Execute(test.workflow(mocks)))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"github.com/shubhamdubey02/apm/config"
+	"github.com/shubhamdubey02/apm/util"
+)
+
+var ErrUnsupportedAuth = errors.New("unsupported auth")
+
+// NewAuth returns how to authenticate against the remote at [url] with
+// [credential]. A nil auth method means the transport's default is used,
+// which is the ssh agent for ssh remotes and no auth for https remotes.
+func NewAuth(url string, credential config.Credential) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, redact(err, url, nil)
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		if credential.Password != "" || credential.Token != "" {
+			return nil, fmt.Errorf("%w: %s is an ssh remote, but a password or token was given; configure its credential under repositories", ErrUnsupportedAuth, util.RedactURL(url))
+		}
+		if credential.SSHKey == "" {
+			return nil, nil
+		}
+
+		user := endpoint.User
+		if credential.Username != "" {
+			user = credential.Username
+		}
+		if user == "" {
+			user = ssh.DefaultUsername
+		}
+		auth, err := ssh.NewPublicKeysFromFile(user, credential.SSHKey, credential.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ssh key for %s: %w", util.RedactURL(url), err)
+		}
+		return auth, nil
+	case "http", "https":
+		if credential.SSHKey != "" {
+			return nil, fmt.Errorf("%w: %s is an %s remote, but an ssh key was given", ErrUnsupportedAuth, util.RedactURL(url), endpoint.Protocol)
+		}
+		if credential.Token != "" {
+			if credential.Username != "" || credential.Password != "" {
+				return nil, fmt.Errorf("%w: both a token and a username/password were given for %s", ErrUnsupportedAuth, util.RedactURL(url))
+			}
+			return &http.TokenAuth{Token: credential.Token}, nil
+		}
+		if credential.Helper && credential.Password == "" {
+			filled, err := fillCredential(url, credential.Username)
+			if err != nil {
+				return nil, err
+			}
+			return &helperAuth{
+				BasicAuth: &http.BasicAuth{
+					Username: filled.Username,
+					Password: filled.Password,
+				},
+				url: url,
+			}, nil
+		}
+		if credential.Username == "" && credential.Password == "" {
+			return nil, nil
+		}
+		return &http.BasicAuth{
+			Username: credential.Username,
+			Password: credential.Password,
+		}, nil
+	default:
+		if credential != (config.Credential{}) {
+			return nil, fmt.Errorf("%w: credentials can't be used for %s remotes", ErrUnsupportedAuth, endpoint.Protocol)
+		}
+		return nil, nil
+	}
+}
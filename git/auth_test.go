@@ -0,0 +1,180 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package git
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/config"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func TestNewAuth(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "id_ecdsa")
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0o600))
+
+	// fake git, which answers git credential fill
+	bin := t.TempDir()
+	script := "#!/bin/sh\nwhile read -r line && [ -n \"$line\" ]; do :; done\necho username=user\necho password=from-helper\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "git"), []byte(script), 0o700))
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name       string
+		url        string
+		credential config.Credential
+		check      func(t *testing.T, auth transport.AuthMethod)
+		wantErr    error
+	}{
+		{
+			name: "https without credentials",
+			url:  "https://github.com/org/repo",
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			name:       "https basic auth",
+			url:        "https://github.com/org/repo",
+			credential: config.Credential{Username: "user", Password: "password"},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				assert.Equal(t, &http.BasicAuth{Username: "user", Password: "password"}, auth)
+			},
+		},
+		{
+			name:       "https token",
+			url:        "https://github.com/org/repo",
+			credential: config.Credential{Token: "token"},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				assert.Equal(t, &http.TokenAuth{Token: "token"}, auth)
+			},
+		},
+		{
+			name:       "https token and password",
+			url:        "https://github.com/org/repo",
+			credential: config.Credential{Token: "token", Password: "password"},
+			wantErr:    ErrUnsupportedAuth,
+		},
+		{
+			name: "https credential helper",
+			url:  "https://github.com/org/repo",
+			credential: config.Credential{
+				Username: "user",
+				Helper:   true,
+			},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				assert.Equal(t, &helperAuth{
+					BasicAuth: &http.BasicAuth{Username: "user", Password: "from-helper"},
+					url:       "https://github.com/org/repo",
+				}, auth)
+			},
+		},
+		{
+			name:       "https ssh key",
+			url:        "https://github.com/org/repo",
+			credential: config.Credential{SSHKey: keyPath},
+			wantErr:    ErrUnsupportedAuth,
+		},
+		{
+			name: "ssh agent",
+			url:  "git@github.com:org/repo.git",
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			name:       "ssh key",
+			url:        "ssh://deploy@github.com/org/repo.git",
+			credential: config.Credential{SSHKey: keyPath},
+			check: func(t *testing.T, auth transport.AuthMethod) {
+				keys, ok := auth.(*ssh.PublicKeys)
+				assert.True(t, ok)
+				assert.Equal(t, "deploy", keys.User)
+			},
+		},
+		{
+			name:       "ssh password",
+			url:        "git@github.com:org/repo.git",
+			credential: config.Credential{Username: "user", Password: "password"},
+			wantErr:    ErrUnsupportedAuth,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auth, err := NewAuth(test.url, test.credential)
+			assert.ErrorIs(t, err, test.wantErr)
+			if test.wantErr != nil {
+				return
+			}
+			test.check(t, auth)
+		})
+	}
+}
+
+func TestHelperAuthReport(t *testing.T) {
+	// fake git, which records what it's told
+	bin := t.TempDir()
+	log := filepath.Join(t.TempDir(), "log")
+	script := "#!/bin/sh\necho \"$2\" >> " + log + "\nwhile read -r line && [ -n \"$line\" ]; do echo \"$line\" >> " + log + "; done\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(bin, "git"), []byte(script), 0o700))
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "approved",
+			want: "approve\nprotocol=https\nhost=github.com\npath=org/repo\nusername=user\npassword=password\n",
+		},
+		{
+			name: "rejected",
+			err:  &fetchError{ref: storage.NewBranchRef("main"), err: transport.ErrAuthenticationRequired},
+			want: "reject\nprotocol=https\nhost=github.com\npath=org/repo\nusername=user\npassword=password\n",
+		},
+		{
+			name: "unrelated failure",
+			err:  errors.New("unreachable"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_ = os.Remove(log)
+
+			auth := &helperAuth{
+				BasicAuth: &http.BasicAuth{Username: "user", Password: "password"},
+				url:       "https://github.com/org/repo",
+			}
+			auth.report(test.err)
+
+			bytes, err := os.ReadFile(log)
+			if test.want == "" {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, string(bytes))
+		})
+	}
+}
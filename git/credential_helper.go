@@ -0,0 +1,114 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/shubhamdubey02/apm/config"
+	"github.com/shubhamdubey02/apm/util"
+)
+
+var (
+	errNoHelperCredential = errors.New("no credential from git credential helpers")
+
+	_ http.AuthMethod = &helperAuth{}
+)
+
+// helperAuth is basic auth filled by git's credential helpers. The helpers
+// are told whether it worked, so they can store or forget it.
+type helperAuth struct {
+	*http.BasicAuth
+	url string
+}
+
+// report approves the credential if [err] is nil, or rejects it if the
+// remote refused it. Helpers are best-effort, so their failures are ignored.
+func (h *helperAuth) report(err error) {
+	switch {
+	case err == nil:
+		_ = runCredentialHelper("approve", h.url, h.Username, h.Password, nil)
+	case errors.Is(err, transport.ErrAuthenticationRequired):
+		_ = runCredentialHelper("reject", h.url, h.Username, h.Password, nil)
+	}
+}
+
+// fillCredential asks git's credential helpers for the username and password
+// of the https remote at [rawURL], using the git credential fill protocol.
+func fillCredential(rawURL string, username string) (config.Credential, error) {
+	stdout := &bytes.Buffer{}
+	if err := runCredentialHelper("fill", rawURL, username, "", stdout); err != nil {
+		return config.Credential{}, err
+	}
+
+	result := config.Credential{}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			result.Username = value
+		case "password":
+			result.Password = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return config.Credential{}, err
+	}
+	if result.Password == "" {
+		return config.Credential{}, fmt.Errorf("%w for %s", errNoHelperCredential, util.RedactURL(rawURL))
+	}
+
+	return result, nil
+}
+
+// runCredentialHelper runs git credential [action] for the remote at [rawURL],
+// writing git's reply to [stdout] if it isn't nil.
+func runCredentialHelper(action string, rawURL string, username string, password string, stdout io.Writer) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if username == "" && u.User != nil {
+		username = u.User.Username()
+	}
+
+	input := &bytes.Buffer{}
+	fmt.Fprintf(input, "protocol=%s\n", u.Scheme)
+	fmt.Fprintf(input, "host=%s\n", u.Host)
+	fmt.Fprintf(input, "path=%s\n", strings.TrimPrefix(u.Path, "/"))
+	if username != "" {
+		fmt.Fprintf(input, "username=%s\n", username)
+	}
+	if password != "" {
+		fmt.Fprintf(input, "password=%s\n", password)
+	}
+	fmt.Fprintln(input)
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("git", "credential", action)
+	cmd.Stdin = input
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// never prompt, apm isn't necessarily run interactively
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w for %s: %s", errNoHelperCredential, util.RedactURL(rawURL), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -4,68 +4,660 @@
 package git
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/MetalBlockchain/metalgo/utils/perms"
+	"github.com/MetalBlockchain/metalgo/version"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+const (
+	remoteName = "origin"
+
+	// unshallowDepth fetches the full history into a shallow checkout, like
+	// git fetch --unshallow. go-git can't fetch without a depth into one.
+	unshallowDepth = math.MaxInt32
 )
 
+var (
+	// deepenFactor is how much deeper each fetch gets when shallow history
+	// isn't enough. Past maxDepth the full history is fetched.
+	deepenFactor = 16
+	maxDepth     = 4096
+)
+
+var (
+	allBranches = config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", remoteName))
+	allTags     = config.RefSpec("+refs/tags/*:refs/tags/*")
+)
+
+var _ error = &fetchError{}
+
+// fetchError means the remote couldn't be fetched from.
+type fetchError struct {
+	ref storage.Ref
+	err error
+}
+
+func (f *fetchError) Error() string {
+	return fmt.Sprintf("failed to fetch %s: %s", f.ref, f.err)
+}
+
+func (f *fetchError) Unwrap() error {
+	return f.err
+}
+
+// Sync is the outcome of syncing a repository.
+type Sync struct {
+	// Commit is the commit that was checked out.
+	Commit plumbing.Hash
+	// ForcePushed is set if the previous commit isn't an ancestor of
+	// Commit, i.e. the remote's history was rewritten.
+	ForcePushed bool
+	// Tag is the tag Commit was resolved from, for latest tag refs.
+	Tag string
+	// Recloned is set if the local checkout was unusable and had to be
+	// cloned again.
+	Recloned bool
+}
+
 type Factory interface {
-	GetRepository(url string, path string, reference plumbing.ReferenceName, auth *http.BasicAuth) (plumbing.Hash, error)
+	// GetRepository syncs the checkout at [path] to [ref] of the remote at
+	// [url], cloning it if needed. [previous] is the commit of the last sync,
+	// or the zero hash if there wasn't one.
+	GetRepository(url string, path string, ref storage.Ref, previous plumbing.Hash, auth transport.AuthMethod) (Sync, error)
 }
 
-type RepositoryFactory struct{}
+type RepositoryFactoryConfig struct {
+	// Depth is how many commits of history are fetched. History is deepened
+	// when it's needed, e.g. to find a pinned commit. Zero fetches the full
+	// history.
+	Depth int
+	// Paths are the directories that are checked out. Everything is checked
+	// out if it's empty.
+	Paths []string
+}
 
-func (f RepositoryFactory) GetRepository(url string, path string, reference plumbing.ReferenceName, auth *http.BasicAuth) (plumbing.Hash, error) {
-	var repo *git.Repository
+func NewRepositoryFactory(config RepositoryFactoryConfig) *RepositoryFactory {
+	return &RepositoryFactory{
+		depth: config.Depth,
+		paths: config.Paths,
+	}
+}
 
-	switch _, err := os.Stat(path); err {
-	case nil:
-		// already exists, so we need to check out the latest changes
-		repo, err = git.PlainOpen(path)
-		if err != nil {
-			return plumbing.ZeroHash, err
+// RepositoryFactory syncs repositories with go-git. The zero value fetches
+// full history and checks out everything.
+type RepositoryFactory struct {
+	depth int
+	paths []string
+}
+
+func (f RepositoryFactory) GetRepository(url string, path string, ref storage.Ref, previous plumbing.Hash, auth transport.AuthMethod) (Sync, error) {
+	// go-git's errors may include the remote's url and credentials
+	sync, err := f.getRepository(url, path, ref, previous, auth)
+	if helper, ok := auth.(*helperAuth); ok {
+		helper.report(err)
+	}
+	return sync, redact(err, url, auth)
+}
+
+func (f RepositoryFactory) getRepository(url string, path string, ref storage.Ref, previous plumbing.Hash, auth transport.AuthMethod) (Sync, error) {
+	if err := ref.Verify(); err != nil {
+		return Sync{}, err
+	}
+
+	switch _, err := os.Stat(path); {
+	case err == nil:
+		result, err := f.sync(url, path, ref, previous, auth)
+		// a failed fetch usually means the remote can't be reached, which a
+		// new clone wouldn't fix
+		var fetchErr *fetchError
+		if err == nil || errors.As(err, &fetchErr) {
+			return result, err
 		}
-		worktree, err := repo.Worktree()
+
+		// the checkout is corrupted, so start over
+		if err := os.RemoveAll(path); err != nil {
+			return Sync{}, err
+		}
+		result, err = f.sync(url, path, ref, previous, auth)
 		if err != nil {
-			return plumbing.ZeroHash, err
+			_ = os.RemoveAll(path)
+			return Sync{}, err
 		}
-		if err := worktree.Pull(
-			// TODO use fetch + checkout instead of pull
-			&git.PullOptions{
-				RemoteName:    "origin",
-				ReferenceName: reference,
-				SingleBranch:  true,
-				Auth:          auth,
-				Progress:      io.Discard,
-			},
-		); err != nil && err != git.NoErrAlreadyUpToDate {
-			return plumbing.ZeroHash, err
+		result.Recloned = true
+		return result, nil
+	case os.IsNotExist(err):
+		result, err := f.sync(url, path, ref, previous, auth)
+		if err != nil {
+			// don't leave a checkout behind that looks corrupted next time
+			_ = os.RemoveAll(path)
 		}
+		return result, err
 	default:
-		if os.IsNotExist(err) {
-			// if we don't have the repo, we need to clone it
-			repo, err = git.PlainClone(path, false, &git.CloneOptions{
-				URL:           url,
-				ReferenceName: reference,
-				SingleBranch:  true,
-				Auth:          auth,
-				Progress:      io.Discard,
+		return Sync{}, err
+	}
+}
+
+// sync fetches [ref] into the checkout at [path] and checks it out.
+func (f RepositoryFactory) sync(url string, path string, ref storage.Ref, previous plumbing.Hash, auth transport.AuthMethod) (Sync, error) {
+	c := &checkout{
+		path: path,
+		url:  url,
+		ref:  ref,
+		auth: auth,
+	}
+	if err := c.open(); err != nil {
+		return Sync{}, err
+	}
+
+	result := Sync{}
+	commit, refSpecs, err := c.fetch(f.depth, &result)
+	if err != nil {
+		return Sync{}, err
+	}
+	result.Commit = commit
+
+	// only branches have history that can be rewritten, pins are moved on
+	// purpose
+	if ref.Type == storage.BranchRef && !previous.IsZero() && previous != commit {
+		ancestor, err := isAncestor(c.repo, previous, commit)
+		if errors.Is(err, plumbing.ErrObjectNotFound) && f.depth > 0 {
+			err = c.deepen(refSpecs, f.depth, func() bool {
+				ancestor, err = isAncestor(c.repo, previous, commit)
+				return !errors.Is(err, plumbing.ErrObjectNotFound)
 			})
-			if err != nil {
-				return plumbing.ZeroHash, err
-			}
-		} else {
-			return plumbing.ZeroHash, err
 		}
+		if err != nil {
+			return Sync{}, err
+		}
+		result.ForcePushed = !ancestor
+	}
+
+	return result, c.checkout(commit, f.paths)
+}
+
+// checkout is a local copy of a remote's ref.
+type checkout struct {
+	path string
+	url  string
+	ref  storage.Ref
+	auth transport.AuthMethod
+	repo *git.Repository
+}
+
+// open opens the checkout, pointing its remote at the url, or creates it if
+// it doesn't exist. It fails if the checkout is corrupted.
+func (c *checkout) open() error {
+	repo, err := git.PlainOpen(c.path)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if _, statErr := os.Stat(c.path); os.IsNotExist(statErr) {
+			return c.init()
+		}
+	}
+	if err != nil {
+		return err
 	}
 
+	// the checked out commit must be readable, or fetching will fail
 	head, err := repo.Head()
 	if err != nil {
+		return err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	if _, err := commit.Tree(); err != nil {
+		return err
+	}
+
+	remote, err := repo.Remote(remoteName)
+	switch {
+	case err == nil && len(remote.Config().URLs) == 1 && remote.Config().URLs[0] == c.url:
+		c.repo = repo
+		return nil
+	case err == nil:
+		if err := repo.DeleteRemote(remoteName); err != nil {
+			return err
+		}
+	case !errors.Is(err, git.ErrRemoteNotFound):
+		return err
+	}
+
+	if _, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{c.url},
+	}); err != nil {
+		return err
+	}
+	c.repo = repo
+	return nil
+}
+
+// init creates an empty repository for the checkout.
+func (c *checkout) init() error {
+	repo, err := git.PlainInit(c.path, false)
+	if err != nil {
+		return err
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{
+		Name: remoteName,
+		URLs: []string{c.url},
+	}); err != nil {
+		return err
+	}
+	c.repo = repo
+	return nil
+}
+
+// fetch fetches the ref [depth] commits deep and returns the commit it
+// points to, along with the refspecs that were needed to get it. Nothing is
+// fetched if the commit is already here.
+func (c *checkout) fetch(depth int, result *Sync) (plumbing.Hash, []config.RefSpec, error) {
+	if c.ref.Type == storage.CommitRef {
+		commit := plumbing.NewHash(c.ref.Name)
+		refSpecs := []config.RefSpec{allBranches, allTags}
+		if c.hasCommit(commit) {
+			return commit, refSpecs, nil
+		}
+
+		// commits can't be fetched directly, so fetch everything they could
+		// be part of until they're found
+		if err := c.fetchDepth(refSpecs, depth); err != nil {
+			return plumbing.ZeroHash, nil, err
+		}
+		if !c.hasCommit(commit) {
+			if err := c.deepen(refSpecs, depth, func() bool {
+				return c.hasCommit(commit)
+			}); err != nil {
+				return plumbing.ZeroHash, nil, err
+			}
+		}
+		if !c.hasCommit(commit) {
+			return plumbing.ZeroHash, nil, fmt.Errorf("%w: commit %s isn't on any branch or tag of the remote", storage.ErrInvalidRef, commit)
+		}
+		return commit, refSpecs, nil
+	}
+
+	remoteRefs, err := c.listRemote()
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+
+	var source, destination plumbing.ReferenceName
+	switch c.ref.Type {
+	case storage.BranchRef:
+		source = plumbing.NewBranchReferenceName(c.ref.Name)
+		destination = plumbing.NewRemoteReferenceName(remoteName, c.ref.Name)
+	case storage.TagRef:
+		source = plumbing.NewTagReferenceName(c.ref.Name)
+		destination = source
+	case storage.LatestTagRef:
+		source, err = latestTag(remoteRefs)
+		if err != nil {
+			return plumbing.ZeroHash, nil, err
+		}
+		destination = source
+		result.Tag = source.Short()
+	}
+	refSpecs := []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", source, destination))}
+
+	remoteRef, ok := remoteRefs[source]
+	if !ok {
+		return plumbing.ZeroHash, nil, &fetchError{
+			ref: c.ref,
+			err: fmt.Errorf("%s doesn't exist on the remote", source.Short()),
+		}
+	}
+
+	if c.repo.Storer.HasEncodedObject(remoteRef) == nil {
+		// already fetched, e.g. by an earlier sync or for another ref
+		if err := c.repo.Storer.SetReference(plumbing.NewHashReference(destination, remoteRef)); err != nil {
+			return plumbing.ZeroHash, nil, err
+		}
+	} else if err := c.fetchDepth(refSpecs, depth); err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+
+	commit, err := resolveReference(c.repo, destination)
+	return commit, refSpecs, err
+}
+
+// listRemote returns the references of the remote.
+func (c *checkout) listRemote() (map[plumbing.ReferenceName]plumbing.Hash, error) {
+	remote, err := c.repo.Remote(remoteName)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := remote.List(&git.ListOptions{Auth: c.auth})
+	if err != nil {
+		return nil, &fetchError{
+			ref: c.ref,
+			err: err,
+		}
+	}
+
+	result := make(map[plumbing.ReferenceName]plumbing.Hash, len(refs))
+	for _, ref := range refs {
+		if ref.Type() == plumbing.HashReference {
+			result[ref.Name()] = ref.Hash()
+		}
+	}
+	return result, nil
+}
+
+// deepen fetches [refSpecs] deeper and deeper than the [depth] that was
+// already fetched, until [found] returns true or the full history was
+// fetched.
+func (c *checkout) deepen(refSpecs []config.RefSpec, depth int, found func() bool) error {
+	for depth > 0 {
+		depth *= deepenFactor
+		if depth > maxDepth {
+			depth = 0
+		}
+
+		if err := c.fetchDepth(refSpecs, depth); err != nil {
+			return err
+		}
+		if found() {
+			return nil
+		}
+	}
+	return nil
+}
+
+// fetchDepth fetches [refSpecs] [depth] commits deep, or the full history if
+// [depth] is zero. A failed fetch leaves the checkout as it was.
+func (c *checkout) fetchDepth(refSpecs []config.RefSpec, depth int) (err error) {
+	shallow, err := c.repo.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+	if len(shallow) > 0 {
+		if depth == 0 {
+			depth = unshallowDepth
+		}
+
+		// go-git walks the history of every ref to tell the remote what's
+		// here, which fails where shallow history ends
+		hidden, err := c.hideReferences()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if restoreErr := c.restoreReferences(hidden); err == nil {
+				err = restoreErr
+			}
+		}()
+	}
+
+	if err := c.repo.Fetch(&git.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs:   refSpecs,
+		Depth:      depth,
+		Auth:       c.auth,
+		Progress:   io.Discard,
+		Force:      true,
+	}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return &fetchError{
+			ref: c.ref,
+			err: err,
+		}
+	}
+	return nil
+}
+
+// hideReferences removes the references that point to commits, and returns
+// them so they can be restored.
+func (c *checkout) hideReferences() ([]*plumbing.Reference, error) {
+	itr, err := c.repo.Storer.IterReferences()
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	var hidden []*plumbing.Reference
+	if err := itr.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			hidden = append(hidden, ref)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, ref := range hidden {
+		if err := c.repo.Storer.RemoveReference(ref.Name()); err != nil {
+			_ = c.restoreReferences(hidden[:i])
+			return nil, err
+		}
+	}
+	return hidden, nil
+}
+
+// restoreReferences puts back the [hidden] references that weren't set again
+// since they were hidden.
+func (c *checkout) restoreReferences(hidden []*plumbing.Reference) error {
+	for _, ref := range hidden {
+		_, err := c.repo.Storer.Reference(ref.Name())
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return err
+		}
+		if err := c.repo.Storer.SetReference(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *checkout) hasCommit(commit plumbing.Hash) bool {
+	_, err := c.repo.CommitObject(commit)
+	return err == nil
+}
+
+// checkout points HEAD at [commit] and replaces the files in the checkout
+// with [paths] of its tree. Branches are checked out, pins leave HEAD
+// detached. go-git can't check out part of a tree, so the files are written
+// directly and the index isn't updated: git status in the checkout shows
+// changes, but apm only reads the files.
+func (c *checkout) checkout(commit plumbing.Hash, paths []string) error {
+	head := plumbing.NewHashReference(plumbing.HEAD, commit)
+	if c.ref.Type == storage.BranchRef {
+		branch := plumbing.NewBranchReferenceName(c.ref.Name)
+		if err := c.repo.Storer.SetReference(plumbing.NewHashReference(branch, commit)); err != nil {
+			return err
+		}
+		head = plumbing.NewSymbolicReference(plumbing.HEAD, branch)
+	}
+	if err := c.repo.Storer.SetReference(head); err != nil {
+		return err
+	}
+
+	commitObject, err := c.repo.CommitObject(commit)
+	if err != nil {
+		return err
+	}
+	tree, err := commitObject.Tree()
+	if err != nil {
+		return err
+	}
+
+	// discard local changes, and anything outside of paths
+	entries, err := os.ReadDir(c.path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == git.GitDirName {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.path, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	if len(paths) == 0 {
+		return writeTree(tree, c.path)
+	}
+	for _, path := range paths {
+		subtree, err := tree.Tree(path)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeTree(subtree, filepath.Join(c.path, path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTree writes the files of [tree] to [dir].
+func writeTree(tree *object.Tree, dir string) error {
+	return tree.Files().ForEach(func(file *object.File) error {
+		// only regular files are definitions
+		mode := os.FileMode(perms.ReadWrite)
+		switch file.Mode {
+		case filemode.Regular, filemode.Deprecated:
+		case filemode.Executable:
+			mode = perms.ReadWriteExecute
+		default:
+			return nil
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside of %s", file.Name, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), perms.ReadWriteExecute); err != nil {
+			return err
+		}
+
+		reader, err := file.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, reader); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// resolveReference returns the commit [reference] points to, peeling
+// annotated tags.
+func resolveReference(repo *git.Repository, reference plumbing.ReferenceName) (plumbing.Hash, error) {
+	resolved, err := repo.Reference(reference, true)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: %w", reference.Short(), err)
+	}
+
+	tag, err := repo.TagObject(resolved.Hash())
+	switch {
+	case err == nil:
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		return commit.Hash, nil
+	case errors.Is(err, plumbing.ErrObjectNotFound):
+		// lightweight tags and branches point to the commit directly
+		return resolved.Hash(), nil
+	default:
 		return plumbing.ZeroHash, err
 	}
+}
+
+// latestTag returns the tag with the highest semantic version.
+func latestTag(refs map[plumbing.ReferenceName]plumbing.Hash) (plumbing.ReferenceName, error) {
+	var (
+		latest        plumbing.ReferenceName
+		latestVersion *version.Semantic
+	)
+	for ref := range refs {
+		if !ref.IsTag() {
+			continue
+		}
+
+		name := ref.Short()
+		tagVersion, err := version.Parse(name)
+		if err != nil {
+			// tags don't need the v prefix
+			tagVersion, err = version.Parse("v" + name)
+		}
+		if err != nil {
+			continue
+		}
+		if latestVersion == nil || tagVersion.Compare(latestVersion) > 0 {
+			latest, latestVersion = ref, tagVersion
+		}
+	}
+
+	if latestVersion == nil {
+		return "", fmt.Errorf("%w: no tags with a semantic version", storage.ErrInvalidRef)
+	}
+	return latest, nil
+}
+
+// isAncestor returns true if [previous] is an ancestor of [latest]. It
+// returns plumbing.ErrObjectNotFound if the history between them is
+// incomplete, e.g. because it's shallow.
+func isAncestor(repo *git.Repository, previous plumbing.Hash, latest plumbing.Hash) (bool, error) {
+	var (
+		incomplete bool
+		seen       = map[plumbing.Hash]struct{}{}
+		queue      = []plumbing.Hash{latest}
+	)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if hash == previous {
+			return true, nil
+		}
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
 
-	return head.Hash(), nil
+		commit, err := repo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			incomplete = true
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		queue = append(queue, commit.ParentHashes...)
+	}
+
+	if incomplete {
+		return false, plumbing.ErrObjectNotFound
+	}
+	return false, nil
 }
@@ -0,0 +1,435 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/file"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var (
+	mainBranch  = plumbing.NewBranchReferenceName("main")
+	otherBranch = plumbing.NewBranchReferenceName("other")
+	mainRef     = storage.NewBranchRef("main")
+	otherRef    = storage.NewBranchRef("other")
+)
+
+// remote is a repository that's synced from.
+type remote struct {
+	t    *testing.T
+	path string
+	repo *git.Repository
+}
+
+// newRemote serves file:// remotes in process instead of through the git
+// binary, unless they're fetched [shallow], which go-git's server doesn't
+// support.
+func newRemote(t *testing.T, shallow bool) *remote {
+	if shallow {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git isn't installed")
+		}
+		client.InstallProtocol("file", file.DefaultClient)
+	} else {
+		client.InstallProtocol("file", server.DefaultServer)
+	}
+
+	path := t.TempDir()
+	repo, err := git.PlainInit(path, false)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, mainBranch)))
+	// the in process server only serves directories with a config
+	config, err := repo.Config()
+	require.NoError(t, err)
+	require.NoError(t, repo.SetConfig(config))
+
+	return &remote{
+		t:    t,
+		path: path,
+		repo: repo,
+	}
+}
+
+// commit commits [contents] to [file] on the checked out branch.
+func (r *remote) commit(file string, contents string) plumbing.Hash {
+	require.NoError(r.t, os.MkdirAll(filepath.Dir(filepath.Join(r.path, file)), 0o700))
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.path, file), []byte(contents), 0o600))
+
+	worktree, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	_, err = worktree.Add(file)
+	require.NoError(r.t, err)
+	hash, err := worktree.Commit(contents, &git.CommitOptions{
+		Author: &object.Signature{Name: "apm", Email: "apm@example.com", When: time.Now()},
+	})
+	require.NoError(r.t, err)
+	return hash
+}
+
+// rewrite points [branch] at a new commit that doesn't share its history.
+func (r *remote) rewrite(branch plumbing.ReferenceName, file string, contents string) plumbing.Hash {
+	require.NoError(r.t, r.repo.Storer.RemoveReference(branch))
+	return r.commit(file, contents)
+}
+
+// rewriteTip replaces the last commit of [branch] with a new one.
+func (r *remote) rewriteTip(branch plumbing.ReferenceName, file string, contents string) plumbing.Hash {
+	tip, err := r.repo.Reference(branch, true)
+	require.NoError(r.t, err)
+	commit, err := r.repo.CommitObject(tip.Hash())
+	require.NoError(r.t, err)
+	require.NoError(r.t, r.repo.Storer.SetReference(plumbing.NewHashReference(branch, commit.ParentHashes[0])))
+	return r.commit(file, contents)
+}
+
+// tag tags the checked out commit. Messages make annotated tags.
+func (r *remote) tag(name string, message string) {
+	head, err := r.repo.Head()
+	require.NoError(r.t, err)
+
+	var opts *git.CreateTagOptions
+	if message != "" {
+		opts = &git.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "apm", Email: "apm@example.com", When: time.Now()},
+			Message: message,
+		}
+	}
+	_, err = r.repo.CreateTag(name, head.Hash(), opts)
+	require.NoError(r.t, err)
+}
+
+func (r *remote) url() string {
+	return "file://" + filepath.Join(r.path, git.GitDirName)
+}
+
+func TestRepositoryFactoryGetRepository(t *testing.T) {
+	shallow := NewRepositoryFactory(RepositoryFactoryConfig{
+		Depth: 1,
+		Paths: []string{"vms", "subnets"},
+	})
+
+	tests := []struct {
+		name    string
+		shallow bool
+		sync    func(t *testing.T, remote *remote, path string)
+	}{
+		{
+			name: "clone",
+			sync: func(t *testing.T, remote *remote, path string) {
+				head := remote.commit("vm.yaml", "v1")
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vm.yaml", "v1")
+			},
+		},
+		{
+			name: "fast forward",
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vm.yaml", "v1")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				head := remote.commit("vm.yaml", "v2")
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vm.yaml", "v2")
+			},
+		},
+		{
+			name: "local changes are discarded",
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vm.yaml", "v1")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(filepath.Join(path, "vm.yaml"), []byte("modified"), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(path, "untracked.yaml"), []byte("untracked"), 0o600))
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: previous}, sync)
+				assertFile(t, path, "vm.yaml", "v1")
+				assert.NoFileExists(t, filepath.Join(path, "untracked.yaml"))
+			},
+		},
+		{
+			name: "force-push",
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vm.yaml", "v1")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				head := remote.rewrite(mainBranch, "vm.yaml", "rewritten")
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head, ForcePushed: true}, sync)
+				assertFile(t, path, "vm.yaml", "rewritten")
+			},
+		},
+		{
+			name: "changed branch",
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vm.yaml", "v1")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				require.NoError(t, remote.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, otherBranch)))
+				require.NoError(t, remote.repo.Storer.SetReference(plumbing.NewHashReference(otherBranch, previous)))
+				head := remote.commit("vm.yaml", "other")
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, otherRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vm.yaml", "other")
+			},
+		},
+		{
+			name: "corrupted checkout",
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vm.yaml", "v1")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				require.NoError(t, os.RemoveAll(filepath.Join(path, ".git", "objects")))
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: previous, Recloned: true}, sync)
+				assertFile(t, path, "vm.yaml", "v1")
+			},
+		},
+		{
+			name: "missing remote",
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vm.yaml", "v1")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				require.NoError(t, os.RemoveAll(remote.path))
+
+				_, err = RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, previous, nil)
+				assert.Error(t, err)
+				// the checkout is kept for when the remote is back
+				assertFile(t, path, "vm.yaml", "v1")
+			},
+		},
+		{
+			name: "tag",
+			sync: func(t *testing.T, remote *remote, path string) {
+				tagged := remote.commit("vm.yaml", "v1")
+				remote.tag("v1.0.0", "release")
+				remote.commit("vm.yaml", "v2")
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, storage.NewTagRef("v1.0.0"), plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: tagged}, sync)
+				assertFile(t, path, "vm.yaml", "v1")
+			},
+		},
+		{
+			name: "missing tag",
+			sync: func(t *testing.T, remote *remote, path string) {
+				remote.commit("vm.yaml", "v1")
+
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, storage.NewTagRef("v1.0.0"), plumbing.ZeroHash, nil)
+				assert.Error(t, err)
+				assert.NoDirExists(t, path)
+			},
+		},
+		{
+			name: "commit",
+			sync: func(t *testing.T, remote *remote, path string) {
+				pinned := remote.commit("vm.yaml", "v1")
+				remote.commit("vm.yaml", "v2")
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, storage.NewCommitRef(pinned.String()), plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: pinned}, sync)
+				assertFile(t, path, "vm.yaml", "v1")
+			},
+		},
+		{
+			name: "unknown commit",
+			sync: func(t *testing.T, remote *remote, path string) {
+				remote.commit("vm.yaml", "v1")
+				unknown := plumbing.Hash{1, 2, 3}
+
+				_, err := RepositoryFactory{}.GetRepository(remote.url(), path, storage.NewCommitRef(unknown.String()), plumbing.ZeroHash, nil)
+				assert.ErrorIs(t, err, storage.ErrInvalidRef)
+			},
+		},
+		{
+			name: "latest tag",
+			sync: func(t *testing.T, remote *remote, path string) {
+				remote.commit("vm.yaml", "v1")
+				remote.tag("v1.9.0", "")
+				latest := remote.commit("vm.yaml", "v2")
+				remote.tag("v1.10.0", "release")
+				remote.commit("vm.yaml", "v3")
+				remote.tag("not-a-version", "")
+
+				sync, err := RepositoryFactory{}.GetRepository(remote.url(), path, storage.NewLatestTagRef(), plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: latest, Tag: "v1.10.0"}, sync)
+				assertFile(t, path, "vm.yaml", "v2")
+			},
+		},
+		{
+			name:    "shallow clone",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				first := remote.commit("vms/vm.yaml", "v1")
+				head := remote.commit("vms/vm.yaml", "v2")
+
+				sync, err := shallow.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vms/vm.yaml", "v2")
+				assertMissingCommit(t, path, first)
+			},
+		},
+		{
+			name:    "shallow fast forward deepens history",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				remote.commit("vms/vm.yaml", "v0")
+				previous := remote.commit("vms/vm.yaml", "v1")
+				_, err := shallow.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				remote.commit("vms/vm.yaml", "v2")
+				head := remote.commit("vms/vm.yaml", "v3")
+
+				sync, err := shallow.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vms/vm.yaml", "v3")
+			},
+		},
+		{
+			name:    "shallow force-push",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vms/vm.yaml", "v1")
+				_, err := shallow.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				head := remote.rewrite(mainBranch, "vms/vm.yaml", "rewritten")
+
+				sync, err := shallow.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head, ForcePushed: true}, sync)
+			},
+		},
+		{
+			name:    "shallow commit pin deepens history",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				pinned := remote.commit("vms/vm.yaml", "v1")
+				remote.commit("vms/vm.yaml", "v2")
+				remote.commit("vms/vm.yaml", "v3")
+
+				sync, err := shallow.GetRepository(remote.url(), path, storage.NewCommitRef(pinned.String()), plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: pinned}, sync)
+				assertFile(t, path, "vms/vm.yaml", "v1")
+			},
+		},
+		{
+			name:    "shallow rewritten tip",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				// deepen past maxDepth to the full history
+				defer func(depth int) {
+					maxDepth = depth
+				}(maxDepth)
+				maxDepth = deepenFactor
+
+				for i := 0; i < 2*deepenFactor; i++ {
+					remote.commit("vms/vm.yaml", fmt.Sprintf("v%d", i))
+				}
+				previous := remote.commit("vms/vm.yaml", "tip")
+				_, err := shallow.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				head := remote.rewriteTip(mainBranch, "vms/vm.yaml", "rewritten")
+
+				sync, err := shallow.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head, ForcePushed: true}, sync)
+				assertFile(t, path, "vms/vm.yaml", "rewritten")
+			},
+		},
+		{
+			name:    "shallow fetch failure keeps the checkout",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				previous := remote.commit("vms/vm.yaml", "v1")
+				_, err := shallow.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				moved := remote.path + ".moved"
+				require.NoError(t, os.Rename(remote.path, moved))
+
+				_, err = shallow.GetRepository(remote.url(), path, mainRef, previous, nil)
+				assert.Error(t, err)
+				assertFile(t, path, "vms/vm.yaml", "v1")
+
+				require.NoError(t, os.Rename(moved, remote.path))
+				head := remote.commit("vms/vm.yaml", "v2")
+				sync, err := shallow.GetRepository(remote.url(), path, mainRef, previous, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vms/vm.yaml", "v2")
+			},
+		},
+		{
+			name:    "sparse checkout",
+			shallow: true,
+			sync: func(t *testing.T, remote *remote, path string) {
+				remote.commit("vms/vm.yaml", "vm")
+				remote.commit("subnets/subnet.yaml", "subnet")
+				head := remote.commit("src/main.go", "package main")
+
+				sync, err := shallow.GetRepository(remote.url(), path, mainRef, plumbing.ZeroHash, nil)
+				require.NoError(t, err)
+				assert.Equal(t, Sync{Commit: head}, sync)
+				assertFile(t, path, "vms/vm.yaml", "vm")
+				assertFile(t, path, "subnets/subnet.yaml", "subnet")
+				assert.NoDirExists(t, filepath.Join(path, "src"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.sync(t, newRemote(t, test.shallow), filepath.Join(t.TempDir(), "checkout"))
+		})
+	}
+}
+
+func assertFile(t *testing.T, dir string, file string, contents string) {
+	bytes, err := os.ReadFile(filepath.Join(dir, file))
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(bytes))
+}
+
+func assertMissingCommit(t *testing.T, path string, commit plumbing.Hash) {
+	repo, err := git.PlainOpen(path)
+	require.NoError(t, err)
+	_, err = repo.CommitObject(commit)
+	assert.ErrorIs(t, err, plumbing.ErrObjectNotFound)
+}
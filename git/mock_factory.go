@@ -11,8 +11,9 @@ import (
 	reflect "reflect"
 
 	plumbing "github.com/go-git/go-git/v5/plumbing"
-	http "github.com/go-git/go-git/v5/plumbing/transport/http"
+	transport "github.com/go-git/go-git/v5/plumbing/transport"
 	gomock "github.com/golang/mock/gomock"
+	storage "github.com/shubhamdubey02/apm/storage"
 )
 
 // MockFactory is a mock of Factory interface.
@@ -39,16 +40,16 @@ func (m *MockFactory) EXPECT() *MockFactoryMockRecorder {
 }
 
 // GetRepository mocks base method.
-func (m *MockFactory) GetRepository(url, path string, reference plumbing.ReferenceName, auth *http.BasicAuth) (plumbing.Hash, error) {
+func (m *MockFactory) GetRepository(url, path string, ref storage.Ref, previous plumbing.Hash, auth transport.AuthMethod) (Sync, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRepository", url, path, reference, auth)
-	ret0, _ := ret[0].(plumbing.Hash)
+	ret := m.ctrl.Call(m, "GetRepository", url, path, ref, previous, auth)
+	ret0, _ := ret[0].(Sync)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRepository indicates an expected call of GetRepository.
-func (mr *MockFactoryMockRecorder) GetRepository(url, path, reference, auth interface{}) *gomock.Call {
+func (mr *MockFactoryMockRecorder) GetRepository(url, path, ref, previous, auth interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepository", reflect.TypeOf((*MockFactory)(nil).GetRepository), url, path, reference, auth)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepository", reflect.TypeOf((*MockFactory)(nil).GetRepository), url, path, ref, previous, auth)
 }
@@ -0,0 +1,69 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package git
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+const redacted = "xxxxx"
+
+var _ error = &redactedError{}
+
+// redactedError hides secrets from an error's message, while keeping the
+// error it wraps available to errors.Is.
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (r *redactedError) Error() string {
+	return r.message
+}
+
+func (r *redactedError) Unwrap() error {
+	return r.err
+}
+
+// redact hides the secrets of [auth] and the password in [rawURL] from [err].
+func redact(err error, rawURL string, auth transport.AuthMethod) error {
+	if err == nil {
+		return nil
+	}
+
+	var secrets []string
+	if u, parseErr := url.Parse(rawURL); parseErr == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password)
+		}
+	}
+	switch auth := auth.(type) {
+	case *http.BasicAuth:
+		secrets = append(secrets, auth.Password)
+	case *helperAuth:
+		secrets = append(secrets, auth.Password)
+	case *http.TokenAuth:
+		secrets = append(secrets, auth.Token)
+	}
+
+	message := err.Error()
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		message = strings.ReplaceAll(message, secret, redacted)
+		message = strings.ReplaceAll(message, url.QueryEscape(secret), redacted)
+	}
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{
+		err:     err,
+		message: message,
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ Logger = &logger{}
+	_ Logger = NoLog{}
+	_ Logger = multiLogger{}
+	_ Logger = &Switch{}
+
+	_ io.Writer = &Writer{}
+)
+
+// Logger is used to report progress and diagnostics to the user.
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+	// Progress reports transient progress, such as how much of a download
+	// has completed. It's only shown to humans watching a terminal.
+	Progress(format string, args ...interface{})
+}
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Warn:
+		return "warn"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return Debug, nil
+	case "info":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %s", level)
+	}
+}
+
+type Format string
+
+const (
+	Text Format = "text"
+	JSON Format = "json"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(strings.ToLower(format)); f {
+	case Text, JSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown log format %s", format)
+	}
+}
+
+type Config struct {
+	Writer io.Writer
+	// ErrorWriter, if set, is written errors to instead of Writer.
+	ErrorWriter io.Writer
+	Level       Level
+	Format      Format
+}
+
+func New(config Config) Logger {
+	errorWriter := config.ErrorWriter
+	if errorWriter == nil {
+		errorWriter = config.Writer
+	}
+
+	return &logger{
+		writer:      config.Writer,
+		errorWriter: errorWriter,
+		level:       config.Level,
+		format:      config.Format,
+		terminal:    isTerminal(config.Writer),
+		now:         time.Now,
+	}
+}
+
+type logger struct {
+	lock sync.Mutex
+
+	writer      io.Writer
+	errorWriter io.Writer
+	level       Level
+	format      Format
+	terminal    bool
+	now         func() time.Time
+}
+
+type entry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"msg"`
+}
+
+func (l *logger) Debug(format string, args ...interface{}) {
+	l.log(Debug, format, args...)
+}
+
+func (l *logger) Info(format string, args ...interface{}) {
+	l.log(Info, format, args...)
+}
+
+func (l *logger) Warn(format string, args ...interface{}) {
+	l.log(Warn, format, args...)
+}
+
+func (l *logger) Error(format string, args ...interface{}) {
+	l.log(Error, format, args...)
+}
+
+func (l *logger) Progress(format string, args ...interface{}) {
+	// progress is noise anywhere other than an interactive terminal
+	if !l.terminal || l.format != Text {
+		return
+	}
+
+	l.log(Info, format, args...)
+}
+
+func (l *logger) log(level Level, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+
+	message := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	writer := l.writer
+	if level == Error {
+		writer = l.errorWriter
+	}
+
+	switch l.format {
+	case JSON:
+		// a log line that can't be written has nowhere else to go
+		_ = json.NewEncoder(writer).Encode(entry{
+			Time:    l.now().UTC(),
+			Level:   level.String(),
+			Message: message,
+		})
+	default:
+		switch level {
+		case Warn:
+			message = "Warning: " + message
+		case Error:
+			message = "Error: " + message
+		}
+		_, _ = fmt.Fprintln(writer, message)
+	}
+}
+
+// NewMulti returns a logger that writes to all of [loggers].
+func NewMulti(loggers ...Logger) Logger {
+	return multiLogger(loggers)
+}
+
+type multiLogger []Logger
+
+func (m multiLogger) Debug(format string, args ...interface{}) {
+	for _, l := range m {
+		l.Debug(format, args...)
+	}
+}
+
+func (m multiLogger) Info(format string, args ...interface{}) {
+	for _, l := range m {
+		l.Info(format, args...)
+	}
+}
+
+func (m multiLogger) Warn(format string, args ...interface{}) {
+	for _, l := range m {
+		l.Warn(format, args...)
+	}
+}
+
+func (m multiLogger) Error(format string, args ...interface{}) {
+	for _, l := range m {
+		l.Error(format, args...)
+	}
+}
+
+func (m multiLogger) Progress(format string, args ...interface{}) {
+	for _, l := range m {
+		l.Progress(format, args...)
+	}
+}
+
+// NewSwitch returns a logger that writes to [log] until Set is called.
+func NewSwitch(log Logger) *Switch {
+	return &Switch{
+		log: log,
+	}
+}
+
+// Switch is a logger whose destination can be changed while it's in use.
+type Switch struct {
+	lock sync.RWMutex
+	log  Logger
+}
+
+// Set makes the switch write to [log].
+func (s *Switch) Set(log Logger) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.log = log
+}
+
+func (s *Switch) get() Logger {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.log
+}
+
+func (s *Switch) Debug(format string, args ...interface{}) {
+	s.get().Debug(format, args...)
+}
+
+func (s *Switch) Info(format string, args ...interface{}) {
+	s.get().Info(format, args...)
+}
+
+func (s *Switch) Warn(format string, args ...interface{}) {
+	s.get().Warn(format, args...)
+}
+
+func (s *Switch) Error(format string, args ...interface{}) {
+	s.get().Error(format, args...)
+}
+
+func (s *Switch) Progress(format string, args ...interface{}) {
+	s.get().Progress(format, args...)
+}
+
+// NoLog discards everything.
+type NoLog struct{}
+
+func (NoLog) Debug(string, ...interface{}) {}
+
+func (NoLog) Info(string, ...interface{}) {}
+
+func (NoLog) Warn(string, ...interface{}) {}
+
+func (NoLog) Error(string, ...interface{}) {}
+
+func (NoLog) Progress(string, ...interface{}) {}
+
+// NewWriter returns a writer that logs every line written to it at [level].
+// This is used to capture the output of install scripts.
+func NewWriter(log Logger, level Level) *Writer {
+	return &Writer{
+		log:   log,
+		level: level,
+	}
+}
+
+type Writer struct {
+	log     Logger
+	level   Level
+	pending []byte
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.write(string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush logs any trailing output that wasn't terminated by a newline.
+func (w *Writer) Flush() {
+	if len(w.pending) == 0 {
+		return
+	}
+
+	w.write(string(w.pending))
+	w.pending = nil
+}
+
+func (w *Writer) write(line string) {
+	switch w.level {
+	case Debug:
+		w.log.Debug("%s", line)
+	case Warn:
+		w.log.Warn("%s", line)
+	case Error:
+		w.log.Error("%s", line)
+	default:
+		w.log.Info("%s", line)
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package logging
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  Level
+		format Format
+		log    func(Logger)
+		want   string
+	}{
+		{
+			name:   "text",
+			level:  Info,
+			format: Text,
+			log: func(log Logger) {
+				log.Info("installing %s...\n", "vm")
+				log.Warn("node is offline")
+				log.Error("failed")
+			},
+			want: "installing vm...\nWarning: node is offline\nError: failed\n",
+		},
+		{
+			name:   "below level",
+			level:  Warn,
+			format: Text,
+			log: func(log Logger) {
+				log.Debug("debug")
+				log.Info("info")
+				log.Warn("warn")
+			},
+			want: "Warning: warn\n",
+		},
+		{
+			name:   "json",
+			level:  Info,
+			format: JSON,
+			log: func(log Logger) {
+				log.Info("installing %s", "vm")
+			},
+			want: `{"time":"1970-01-01T00:00:00Z","level":"info","msg":"installing vm"}` + "\n",
+		},
+		{
+			name:   "progress is hidden when not on a terminal",
+			level:  Debug,
+			format: Text,
+			log: func(log Logger) {
+				log.Progress("50%%")
+			},
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			log := New(Config{
+				Writer: buf,
+				Level:  test.level,
+				Format: test.format,
+			})
+			log.(*logger).now = func() time.Time { return time.Unix(0, 0) }
+
+			test.log(log)
+			assert.Equal(t, test.want, buf.String())
+		})
+	}
+}
+
+func TestLoggerErrorWriter(t *testing.T) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	log := New(Config{
+		Writer:      out,
+		ErrorWriter: errOut,
+		Level:       Info,
+		Format:      Text,
+	})
+
+	log.Info("installing")
+	log.Warn("node is offline")
+	log.Error("failed")
+
+	assert.Equal(t, "installing\nWarning: node is offline\n", out.String())
+	assert.Equal(t, "Error: failed\n", errOut.String())
+}
+
+func TestWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(New(Config{
+		Writer: buf,
+		Level:  Info,
+		Format: Text,
+	}), Info)
+
+	_, err := w.Write([]byte("first line\nsecond "))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("line\nunterminated"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first line\nsecond line\n", buf.String())
+
+	w.Flush()
+	assert.Equal(t, "first line\nsecond line\nunterminated\n", buf.String())
+}
+
+func TestSwitch(t *testing.T) {
+	first := &bytes.Buffer{}
+	second := &bytes.Buffer{}
+
+	log := NewSwitch(New(Config{Writer: first, Level: Info, Format: Text}))
+	log.Info("first")
+	log.Set(New(Config{Writer: second, Level: Info, Format: Text}))
+	log.Info("second")
+
+	assert.Equal(t, "first\n", first.String())
+	assert.Equal(t, "second\n", second.String())
+}
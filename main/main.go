@@ -15,12 +15,12 @@ import (
 func main() {
 	apm, err := cmd.New(afero.NewOsFs())
 	if err != nil {
-		fmt.Printf("Failed to initialize the apm command: %s.\n", err)
+		fmt.Fprintf(os.Stderr, "Failed to initialize the apm command: %s.\n", err)
 		os.Exit(1)
 	}
 
 	if err := apm.Execute(); err != nil {
-		fmt.Printf("Unexpected error %s.\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Unexpected error %s.\n", err)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
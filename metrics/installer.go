@@ -0,0 +1,66 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+var _ workflow.Installer = &Installer{}
+
+type InstallerConfig struct {
+	Installer workflow.Installer
+	Metrics   *Metrics
+	Fs        afero.Fs
+}
+
+func NewInstaller(config InstallerConfig) *Installer {
+	return &Installer{
+		installer: config.Installer,
+		metrics:   config.Metrics,
+		fs:        config.Fs,
+		now:       time.Now,
+	}
+}
+
+// Installer reports download and install script metrics for the installer it
+// wraps.
+type Installer struct {
+	installer workflow.Installer
+	metrics   *Metrics
+	fs        afero.Fs
+	now       func() time.Time
+}
+
+func (i *Installer) Download(url string, path string) error {
+	start := i.now()
+	if err := i.installer.Download(url, path); err != nil {
+		return err
+	}
+	duration := i.now().Sub(start)
+
+	var size int64
+	if info, err := i.fs.Stat(path); err == nil {
+		size = info.Size()
+	}
+
+	i.metrics.ObserveDownload(size, duration)
+	return nil
+}
+
+func (i *Installer) Decompress(source string, dest string) error {
+	return i.installer.Decompress(source, dest)
+}
+
+func (i *Installer) Install(workingDir string, args ...string) error {
+	start := i.now()
+	err := i.installer.Install(workingDir, args...)
+	i.metrics.ObserveInstallScript(i.now().Sub(start))
+
+	return err
+}
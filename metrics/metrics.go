@@ -0,0 +1,138 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/MetalBlockchain/metalgo/utils/wrappers"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+const (
+	typeLabel    = "type"
+	outcomeLabel = "outcome"
+)
+
+// Metrics are the prometheus metrics reported about apm operations.
+type Metrics struct {
+	operations       *prometheus.CounterVec
+	operationSeconds *prometheus.HistogramVec
+	lastOperation    *prometheus.GaugeVec
+
+	downloadBytes        prometheus.Counter
+	downloadSeconds      prometheus.Histogram
+	checksumFailures     prometheus.Counter
+	installScriptSeconds prometheus.Histogram
+
+	installedVMs    prometheus.Gauge
+	pendingUpgrades prometheus.Gauge
+}
+
+// New registers the apm's metrics with [registerer] under [namespace].
+func New(namespace string, registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Number of operations performed, by type and outcome",
+		}, []string{typeLabel, outcomeLabel}),
+		operationSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Time taken by operations, by type",
+			Buckets:   []float64{.1, 1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		}, []string{typeLabel}),
+		lastOperation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_operation_timestamp_seconds",
+			Help:      "Unix time the last operation of each type and outcome finished",
+		}, []string{typeLabel, outcomeLabel}),
+		downloadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "download_bytes_total",
+			Help:      "Number of bytes of vm archives downloaded",
+		}),
+		downloadSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "download_duration_seconds",
+			Help:      "Time taken to download vm archives",
+			Buckets:   []float64{.1, .5, 1, 5, 15, 30, 60, 120, 300},
+		}),
+		checksumFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "checksum_failures_total",
+			Help:      "Number of vm archives that didn't match their checksum",
+		}),
+		installScriptSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "install_script_duration_seconds",
+			Help:      "Time taken by vm install scripts",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		}),
+		installedVMs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "installed_vms",
+			Help:      "Number of installed vms",
+		}),
+		pendingUpgrades: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pending_upgrades",
+			Help:      "Number of installed vms with a newer version available",
+		}),
+	}
+
+	errs := wrappers.Errs{}
+	errs.Add(
+		registerer.Register(m.operations),
+		registerer.Register(m.operationSeconds),
+		registerer.Register(m.lastOperation),
+		registerer.Register(m.downloadBytes),
+		registerer.Register(m.downloadSeconds),
+		registerer.Register(m.checksumFailures),
+		registerer.Register(m.installScriptSeconds),
+		registerer.Register(m.installedVMs),
+		registerer.Register(m.pendingUpgrades),
+	)
+
+	return m, errs.Err
+}
+
+// ObserveOperation records an operation finished by the workflow engine and
+// the error its workflow returned.
+func (m *Metrics) ObserveOperation(operation storage.Operation, err error) {
+	typ := string(operation.Type)
+	outcome := string(operation.Outcome)
+
+	m.operations.WithLabelValues(typ, outcome).Inc()
+	m.operationSeconds.WithLabelValues(typ).Observe(operation.EndTime.Sub(operation.StartTime).Seconds())
+	m.lastOperation.WithLabelValues(typ, outcome).Set(float64(operation.EndTime.Unix()))
+
+	// the operations that triggered the install fail with the same error, so
+	// only the install itself is counted
+	if operation.Type == storage.InstallOperation && errors.Is(err, workflow.ErrChecksumMismatch) {
+		m.checksumFailures.Inc()
+	}
+}
+
+func (m *Metrics) ObserveDownload(bytes int64, duration time.Duration) {
+	m.downloadBytes.Add(float64(bytes))
+	m.downloadSeconds.Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveInstallScript(duration time.Duration) {
+	m.installScriptSeconds.Observe(duration.Seconds())
+}
+
+func (m *Metrics) SetInstalledVMs(n int) {
+	m.installedVMs.Set(float64(n))
+}
+
+func (m *Metrics) SetPendingUpgrades(n int) {
+	m.pendingUpgrades.Set(float64(n))
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+func TestObserveOperation(t *testing.T) {
+	start := time.Unix(100, 0)
+	checksumErr := &workflow.ChecksumMismatchError{Expected: "a", Actual: "b"}
+
+	tests := []struct {
+		name             string
+		operation        storage.Operation
+		err              error
+		wantOperations   float64
+		wantChecksumFail float64
+	}{
+		{
+			name: "succeeded",
+			operation: storage.Operation{
+				Type:    storage.UpgradeOperation,
+				Outcome: storage.Succeeded,
+			},
+			wantOperations: 1,
+		},
+		{
+			name: "install with wrong checksum",
+			operation: storage.Operation{
+				Type:    storage.InstallOperation,
+				Outcome: storage.Failed,
+			},
+			err:              checksumErr,
+			wantOperations:   1,
+			wantChecksumFail: 1,
+		},
+		{
+			name: "upgrade failing because of an install with wrong checksum",
+			operation: storage.Operation{
+				Type:    storage.UpgradeOperation,
+				Outcome: storage.Failed,
+			},
+			err:              fmt.Errorf("upgrade failed: %w", checksumErr),
+			wantOperations:   1,
+			wantChecksumFail: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := New("apm", prometheus.NewRegistry())
+			assert.NoError(t, err)
+
+			test.operation.StartTime = start
+			test.operation.EndTime = start.Add(time.Second)
+			m.ObserveOperation(test.operation, test.err)
+
+			operations := m.operations.WithLabelValues(string(test.operation.Type), string(test.operation.Outcome))
+			assert.Equal(t, test.wantOperations, testutil.ToFloat64(operations))
+			assert.Equal(t, test.wantChecksumFail, testutil.ToFloat64(m.checksumFailures))
+
+			last := m.lastOperation.WithLabelValues(string(test.operation.Type), string(test.operation.Outcome))
+			assert.Equal(t, float64(101), testutil.ToFloat64(last))
+		})
+	}
+}
+
+func TestNewRegistersOnce(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	_, err := New("apm", registry)
+	assert.NoError(t, err)
+
+	_, err = New("apm", registry)
+	assert.Error(t, err)
+}
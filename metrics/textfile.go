@@ -0,0 +1,128 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// WriteTextfile writes the metrics gathered from [gatherer] to [path], for the
+// node_exporter textfile collector. Each apm run only counts what it did
+// itself, so its counters and histograms are added to the ones already in the
+// file. Its gauges replace the ones in the file, and series it didn't report,
+// such as the gauges of other profiles, are kept.
+func WriteTextfile(path string, gatherer prometheus.Gatherer) error {
+	previous, err := readTextfile(path)
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced atomically so the collector never sees a partial
+	// write
+	return prometheus.WriteToTextfile(path, prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			return nil, err
+		}
+
+		return merge(previous, families), nil
+	}))
+}
+
+func readTextfile(path string) (map[string]*dto.MetricFamily, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the metrics in %s: %w", path, err)
+	}
+
+	return families, nil
+}
+
+// merge adds the [previous] metrics to the [current] ones.
+func merge(previous map[string]*dto.MetricFamily, current []*dto.MetricFamily) []*dto.MetricFamily {
+	result := make([]*dto.MetricFamily, 0, len(current)+len(previous))
+	for _, family := range current {
+		if old, ok := previous[family.GetName()]; ok && old.GetType() == family.GetType() {
+			mergeFamily(old, family)
+		}
+
+		delete(previous, family.GetName())
+		result = append(result, family)
+	}
+	for _, family := range previous {
+		result = append(result, family)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+	return result
+}
+
+func mergeFamily(previous *dto.MetricFamily, current *dto.MetricFamily) {
+	metrics := make(map[string]*dto.Metric, len(current.Metric))
+	for _, metric := range current.Metric {
+		metrics[labels(metric)] = metric
+	}
+
+	for _, old := range previous.Metric {
+		metric, ok := metrics[labels(old)]
+		if !ok {
+			current.Metric = append(current.Metric, old)
+			continue
+		}
+
+		switch current.GetType() {
+		case dto.MetricType_COUNTER:
+			value := metric.GetCounter().GetValue() + old.GetCounter().GetValue()
+			metric.Counter.Value = &value
+		case dto.MetricType_HISTOGRAM:
+			addHistogram(metric.Histogram, old.Histogram)
+		}
+	}
+}
+
+func addHistogram(histogram *dto.Histogram, previous *dto.Histogram) {
+	count := histogram.GetSampleCount() + previous.GetSampleCount()
+	sum := histogram.GetSampleSum() + previous.GetSampleSum()
+	histogram.SampleCount = &count
+	histogram.SampleSum = &sum
+
+	previousCounts := make(map[float64]uint64, len(previous.Bucket))
+	for _, bucket := range previous.Bucket {
+		previousCounts[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+	}
+	for _, bucket := range histogram.Bucket {
+		count := bucket.GetCumulativeCount() + previousCounts[bucket.GetUpperBound()]
+		bucket.CumulativeCount = &count
+	}
+}
+
+// labels identifies a series of a metric family.
+func labels(metric *dto.Metric) string {
+	pairs := make([]string, 0, len(metric.Label))
+	for _, label := range metric.Label {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
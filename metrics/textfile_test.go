@@ -0,0 +1,85 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apm.prom")
+
+	// run records an upgrade made by an apm run for [profile], which has
+	// [installed] vms afterwards
+	run := func(profile string, installed int) {
+		registry := prometheus.NewRegistry()
+		m, err := New("apm", prometheus.WrapRegistererWith(prometheus.Labels{"profile": profile}, registry))
+		assert.NoError(t, err)
+
+		m.ObserveOperation(storage.Operation{
+			Type:      storage.UpgradeOperation,
+			Outcome:   storage.Succeeded,
+			StartTime: time.Unix(100, 0),
+			EndTime:   time.Unix(102, 0),
+		}, nil)
+		m.SetInstalledVMs(installed)
+
+		assert.NoError(t, WriteTextfile(path, registry))
+	}
+
+	run("a", 3)
+	run("b", 1)
+	run("a", 2)
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(file)
+	assert.NoError(t, err)
+
+	// value returns the value of the series of [name] for [profile]
+	value := func(name string, profile string) *dto.Metric {
+		for _, metric := range families[name].GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "profile" && label.GetValue() == profile {
+					return metric
+				}
+			}
+		}
+		return nil
+	}
+
+	assert.Equal(t, 2.0, value("apm_operations_total", "a").GetCounter().GetValue())
+	assert.Equal(t, 1.0, value("apm_operations_total", "b").GetCounter().GetValue())
+	assert.Equal(t, uint64(2), value("apm_operation_duration_seconds", "a").GetHistogram().GetSampleCount())
+	assert.Equal(t, 4.0, value("apm_operation_duration_seconds", "a").GetHistogram().GetSampleSum())
+	for _, bucket := range value("apm_operation_duration_seconds", "a").GetHistogram().GetBucket() {
+		if bucket.GetUpperBound() >= 5 {
+			assert.Equal(t, uint64(2), bucket.GetCumulativeCount())
+		}
+	}
+	assert.Equal(t, 102.0, value("apm_last_operation_timestamp_seconds", "a").GetGauge().GetValue())
+	assert.Equal(t, 2.0, value("apm_installed_vms", "a").GetGauge().GetValue())
+	assert.Equal(t, 1.0, value("apm_installed_vms", "b").GetGauge().GetValue())
+}
+
+func TestWriteTextfileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apm.prom")
+	assert.NoError(t, os.WriteFile(path, []byte("not metrics{"), 0o600))
+
+	assert.Error(t, WriteTextfile(path, prometheus.NewRegistry()))
+}
@@ -0,0 +1,209 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/spf13/afero"
+)
+
+const (
+	// TrackSubnetsKey is the node config key listing the subnets the node
+	// tracks.
+	TrackSubnetsKey = "track-subnets"
+	// WhitelistedSubnetsKey is what older nodes call TrackSubnetsKey.
+	WhitelistedSubnetsKey = "whitelisted-subnets"
+
+	backupSuffix = ".bak"
+	tmpSuffix    = ".tmp"
+)
+
+// trackSubnetsVersion is the first node version that reads TrackSubnetsKey.
+var trackSubnetsVersion = &version.Semantic{Major: 1, Minor: 9, Patch: 6}
+
+// TrackSubnetsKeyFor returns the key a node at [nodeVersion] reads its
+// tracked subnets from. Nodes of unknown versions get the legacy key.
+func TrackSubnetsKeyFor(nodeVersion *version.Semantic) string {
+	if nodeVersion == nil || nodeVersion.Compare(trackSubnetsVersion) < 0 {
+		return WhitelistedSubnetsKey
+	}
+	return TrackSubnetsKey
+}
+
+// Config edits a node's json config file. Keys apm doesn't manage are kept
+// as they are.
+type Config struct {
+	fs   afero.Fs
+	path string
+}
+
+func NewConfig(fs afero.Fs, path string) *Config {
+	return &Config{
+		fs:   fs,
+		path: path,
+	}
+}
+
+// Path returns where the config file is.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// TrackedSubnets returns the subnets the node is configured to track.
+func (c *Config) TrackedSubnets() ([]ids.ID, error) {
+	values, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+
+	_, subnets, err := trackedSubnets(values)
+	return subnets, err
+}
+
+// TrackSubnet adds [subnetID] to the tracked subnets. If the config doesn't
+// track any yet, they're written under the key [nodeVersion] reads, which may
+// be nil if it's unknown. It returns false if it was already tracked, in which
+// case the file isn't changed.
+func (c *Config) TrackSubnet(subnetID ids.ID, nodeVersion *version.Semantic) (bool, error) {
+	return c.updateSubnets(TrackSubnetsKeyFor(nodeVersion), func(subnets []ids.ID) ([]ids.ID, bool) {
+		for _, subnet := range subnets {
+			if subnet == subnetID {
+				return subnets, false
+			}
+		}
+
+		return append(subnets, subnetID), true
+	})
+}
+
+// UntrackSubnet removes [subnetID] from the tracked subnets. It returns false
+// if it wasn't tracked, in which case the file isn't changed.
+func (c *Config) UntrackSubnet(subnetID ids.ID) (bool, error) {
+	return c.updateSubnets(TrackSubnetsKey, func(subnets []ids.ID) ([]ids.ID, bool) {
+		for i, subnet := range subnets {
+			if subnet == subnetID {
+				return append(subnets[:i], subnets[i+1:]...), true
+			}
+		}
+
+		return subnets, false
+	})
+}
+
+// updateSubnets writes the tracked subnets [update] returns, under
+// [defaultKey] if the config doesn't track any yet.
+func (c *Config) updateSubnets(defaultKey string, update func([]ids.ID) ([]ids.ID, bool)) (bool, error) {
+	values, err := c.read()
+	if err != nil {
+		return false, err
+	}
+
+	key, subnets, err := trackedSubnets(values)
+	if err != nil {
+		return false, err
+	}
+	if key == "" {
+		key = defaultKey
+	}
+
+	subnets, changed := update(subnets)
+	if !changed {
+		return false, nil
+	}
+
+	strs := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		strs = append(strs, subnet.String())
+	}
+	value, err := json.Marshal(strings.Join(strs, ","))
+	if err != nil {
+		return false, err
+	}
+	values[key] = value
+
+	return true, c.write(values)
+}
+
+// trackedSubnets returns the key the tracked subnets are under, and the
+// subnets. The key is empty if neither key is present.
+func trackedSubnets(values map[string]json.RawMessage) (string, []ids.ID, error) {
+	key := TrackSubnetsKey
+	raw, ok := values[key]
+	if !ok {
+		key, raw = WhitelistedSubnetsKey, values[WhitelistedSubnetsKey]
+	}
+	if raw == nil {
+		return "", nil, nil
+	}
+
+	value := ""
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", nil, fmt.Errorf("%s must be a comma separated string: %w", key, err)
+	}
+
+	subnets := []ids.ID{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		subnetID, err := ids.FromString(field)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid subnet id %s in %s: %w", field, key, err)
+		}
+		subnets = append(subnets, subnetID)
+	}
+
+	return key, subnets, nil
+}
+
+func (c *Config) read() (map[string]json.RawMessage, error) {
+	bytes, err := afero.ReadFile(c.fs, c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal(bytes, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse node config %s: %w", c.path, err)
+	}
+
+	return values, nil
+}
+
+// write backs up the current file, and then replaces it with [values].
+func (c *Config) write(values map[string]json.RawMessage) error {
+	info, err := c.fs.Stat(c.path)
+	if err != nil {
+		return err
+	}
+	previous, err := afero.ReadFile(c.fs, c.path)
+	if err != nil {
+		return err
+	}
+	if err := afero.WriteFile(c.fs, c.path+backupSuffix, previous, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	// encoding/json sorts the keys
+	bytes, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	bytes = append(bytes, '\n')
+
+	// write to a temporary file first so the node never sees a partial file
+	tmp := c.path + tmpSuffix
+	if err := afero.WriteFile(c.fs, tmp, bytes, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return c.fs.Rename(tmp, c.path)
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const path = "/node/config.json"
+
+var (
+	subnet1 = ids.ID{1}
+	subnet2 = ids.ID{2}
+)
+
+func TestConfigTrackSubnet(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		subnet      ids.ID
+		nodeVersion *version.Semantic
+		wantAdded   bool
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:      "no tracked subnets on an unknown node",
+			config:    `{"network-id": "tahoe"}`,
+			subnet:    subnet1,
+			wantAdded: true,
+			want: "{\n" +
+				"  \"network-id\": \"tahoe\",\n" +
+				"  \"whitelisted-subnets\": \"" + subnet1.String() + "\"\n" +
+				"}\n",
+		},
+		{
+			name:        "no tracked subnets on an older node",
+			config:      `{"network-id": "tahoe"}`,
+			subnet:      subnet1,
+			nodeVersion: &version.Semantic{Major: 1, Minor: 7, Patch: 17},
+			wantAdded:   true,
+			want: "{\n" +
+				"  \"network-id\": \"tahoe\",\n" +
+				"  \"whitelisted-subnets\": \"" + subnet1.String() + "\"\n" +
+				"}\n",
+		},
+		{
+			name:        "no tracked subnets on a newer node",
+			config:      `{"network-id": "tahoe"}`,
+			subnet:      subnet1,
+			nodeVersion: &version.Semantic{Major: 1, Minor: 9, Patch: 6},
+			wantAdded:   true,
+			want: "{\n" +
+				"  \"network-id\": \"tahoe\",\n" +
+				"  \"track-subnets\": \"" + subnet1.String() + "\"\n" +
+				"}\n",
+		},
+		{
+			name:      "appends to tracked subnets",
+			config:    `{"track-subnets": "` + subnet1.String() + `"}`,
+			subnet:    subnet2,
+			wantAdded: true,
+			want: "{\n" +
+				"  \"track-subnets\": \"" + subnet1.String() + "," + subnet2.String() + "\"\n" +
+				"}\n",
+		},
+		{
+			name:        "uses legacy key",
+			config:      `{"whitelisted-subnets": "` + subnet1.String() + `"}`,
+			subnet:      subnet2,
+			nodeVersion: &version.Semantic{Major: 1, Minor: 9, Patch: 6},
+			wantAdded:   true,
+			want: "{\n" +
+				"  \"whitelisted-subnets\": \"" + subnet1.String() + "," + subnet2.String() + "\"\n" +
+				"}\n",
+		},
+		{
+			name:      "already tracked",
+			config:    `{"track-subnets": "` + subnet1.String() + `"}`,
+			subnet:    subnet1,
+			wantAdded: false,
+			want:      `{"track-subnets": "` + subnet1.String() + `"}`,
+		},
+		{
+			name:    "invalid tracked subnet",
+			config:  `{"track-subnets": "garbage"}`,
+			subnet:  subnet1,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			config:  `{`,
+			subnet:  subnet1,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			assert.NoError(t, afero.WriteFile(fs, path, []byte(test.config), 0o600))
+
+			added, err := NewConfig(fs, path).TrackSubnet(test.subnet, test.nodeVersion)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantAdded, added)
+
+			bytes, err := afero.ReadFile(fs, path)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, string(bytes))
+
+			backup, err := afero.ReadFile(fs, path+backupSuffix)
+			if test.wantAdded {
+				assert.NoError(t, err)
+				assert.Equal(t, test.config, string(backup))
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigUntrackSubnet(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	config := `{"track-subnets": "` + subnet1.String() + `,` + subnet2.String() + `"}`
+	assert.NoError(t, afero.WriteFile(fs, path, []byte(config), 0o600))
+	c := NewConfig(fs, path)
+
+	removed, err := c.UntrackSubnet(subnet1)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	subnets, err := c.TrackedSubnets()
+	assert.NoError(t, err)
+	assert.Equal(t, []ids.ID{subnet2}, subnets)
+
+	removed, err = c.UntrackSubnet(subnet1)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestConfigMissingFile(t *testing.T) {
+	_, err := NewConfig(afero.NewMemMapFs(), path).TrackSubnet(subnet1, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,221 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/MetalBlockchain/metalgo/utils/perms"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+)
+
+const (
+	subnetsDir      = "subnets"
+	chainsDir       = "chains"
+	chainConfigFile = "config.json"
+	upgradeFile     = "upgrade.json"
+	jsonExtension   = ".json"
+)
+
+// Change describes how a config file was written.
+type Change struct {
+	Path string
+	// Created is true if the file didn't exist.
+	Created bool
+	// Diff is a unified diff of the changes to an existing file. It's empty
+	// if the file didn't change.
+	Diff string
+}
+
+// Changed returns true if the file was written.
+func (c Change) Changed() bool {
+	return c.Created || c.Diff != ""
+}
+
+// Configs is the directory the node reads subnet and chain configs from,
+// laid out as subnets/<subnet id>.json and chains/<chain id>/.
+type Configs struct {
+	fs  afero.Fs
+	dir string
+	// backupDir mirrors dir with the configs that were overwritten. It's
+	// outside of dir, since the node refuses to start if more than one file
+	// matches chains/<chain id>/config.*.
+	backupDir string
+}
+
+func NewConfigs(fs afero.Fs, dir string, backupDir string) *Configs {
+	return &Configs{
+		fs:        fs,
+		dir:       dir,
+		backupDir: backupDir,
+	}
+}
+
+// File is a config file the node reads, and what the apm writes to it.
+type File struct {
+	Path  string
+	Value map[string]interface{}
+}
+
+// SubnetConfig returns the config file of the subnet with [subnetID].
+func (c *Configs) SubnetConfig(subnetID ids.ID, config map[string]interface{}) File {
+	return File{
+		Path:  filepath.Join(c.dir, subnetsDir, subnetID.String()+jsonExtension),
+		Value: config,
+	}
+}
+
+// ChainConfig returns the config file of the blockchain with [chainID].
+func (c *Configs) ChainConfig(chainID ids.ID, config map[string]interface{}) File {
+	return File{
+		Path:  filepath.Join(c.dir, chainsDir, chainID.String(), chainConfigFile),
+		Value: config,
+	}
+}
+
+// ChainUpgrade returns the upgrade file of the blockchain with [chainID].
+func (c *Configs) ChainUpgrade(chainID ids.ID, upgrade map[string]interface{}) File {
+	return File{
+		Path:  filepath.Join(c.dir, chainsDir, chainID.String(), upgradeFile),
+		Value: upgrade,
+	}
+}
+
+// WriteSubnetConfig writes the config of the subnet with [subnetID].
+func (c *Configs) WriteSubnetConfig(subnetID ids.ID, config map[string]interface{}) (Change, error) {
+	return c.Write(c.SubnetConfig(subnetID, config))
+}
+
+// WriteChainConfig writes the config of the blockchain with [chainID].
+func (c *Configs) WriteChainConfig(chainID ids.ID, config map[string]interface{}) (Change, error) {
+	return c.Write(c.ChainConfig(chainID, config))
+}
+
+// WriteChainUpgrade writes the upgrade file of the blockchain with [chainID].
+func (c *Configs) WriteChainUpgrade(chainID ids.ID, upgrade map[string]interface{}) (Change, error) {
+	return c.Write(c.ChainUpgrade(chainID, upgrade))
+}
+
+// Diff returns how Write would change [file], without changing it.
+func (c *Configs) Diff(file File) (Change, error) {
+	change, _, _, err := c.diff(file)
+	return change, err
+}
+
+// Write writes [file] as json. An existing file that's different is backed up
+// first, unless it already was: the backup is always the version from before
+// the apm first changed it.
+func (c *Configs) Write(file File) (Change, error) {
+	change, previous, bytes, err := c.diff(file)
+	if err != nil || !change.Changed() {
+		return change, err
+	}
+
+	if !change.Created {
+		if err := c.backup(file.Path, previous); err != nil {
+			return change, err
+		}
+	}
+
+	if err := c.fs.MkdirAll(filepath.Dir(file.Path), perms.ReadWriteExecute); err != nil {
+		return change, err
+	}
+
+	return change, afero.WriteFile(c.fs, file.Path, bytes, perms.ReadWrite)
+}
+
+// diff returns how [file] would change, along with its current and new
+// contents.
+func (c *Configs) diff(file File) (Change, []byte, []byte, error) {
+	change := Change{Path: file.Path}
+
+	bytes, err := json.MarshalIndent(file.Value, "", "  ")
+	if err != nil {
+		return change, nil, nil, err
+	}
+	bytes = append(bytes, '\n')
+
+	previous, err := afero.ReadFile(c.fs, file.Path)
+	switch {
+	case os.IsNotExist(err):
+		change.Created = true
+		return change, nil, bytes, nil
+	case err != nil:
+		return change, nil, nil, err
+	}
+
+	change.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(previous)),
+		B:        difflib.SplitLines(string(bytes)),
+		FromFile: file.Path,
+		ToFile:   file.Path,
+		Context:  3,
+	})
+	return change, previous, bytes, err
+}
+
+// Restore undoes writing the config at [path], which was returned in a
+// Change. The version it overwrote is put back if it was backed up, and
+// otherwise the config is deleted. It returns true if a backup was put back.
+// It's not an error if the config doesn't exist.
+func (c *Configs) Restore(path string) (bool, error) {
+	backup, err := c.backupPath(path)
+	if err != nil {
+		return false, err
+	}
+	// the backup may be on another filesystem, so it's copied back
+	previous, err := afero.ReadFile(c.fs, backup)
+	switch {
+	case err == nil:
+		if err := afero.WriteFile(c.fs, path, previous, perms.ReadWrite); err != nil {
+			return false, err
+		}
+		return true, c.fs.Remove(backup)
+	case !os.IsNotExist(err):
+		return false, err
+	}
+
+	if err := c.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// backup saves [previous], the contents of the config at [path], unless an
+// earlier version was already saved.
+func (c *Configs) backup(path string, previous []byte) error {
+	backup, err := c.backupPath(path)
+	if err != nil {
+		return err
+	}
+	if _, err := c.fs.Stat(backup); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := c.fs.MkdirAll(filepath.Dir(backup), perms.ReadWriteExecute); err != nil {
+		return err
+	}
+	return afero.WriteFile(c.fs, backup, previous, perms.ReadWrite)
+}
+
+// backupPath returns where the config at [path] is backed up.
+func (c *Configs) backupPath(path string) (string, error) {
+	relative, err := filepath.Rel(c.dir, path)
+	if err != nil {
+		return "", err
+	}
+	if relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s isn't in %s", path, c.dir)
+	}
+	return filepath.Join(c.backupDir, relative), nil
+}
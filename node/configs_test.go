@@ -0,0 +1,183 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testConfigsDir = "/node/configs"
+	testBackupDir  = "/apm/config-backups"
+)
+
+func TestConfigsWrite(t *testing.T) {
+	chainID := ids.ID{3}
+	path := filepath.Join(testConfigsDir, "chains", chainID.String(), "config.json")
+	backupPath := filepath.Join(testBackupDir, "chains", chainID.String(), "config.json")
+
+	tests := []struct {
+		name        string
+		existing    string
+		config      map[string]interface{}
+		wantCreated bool
+		wantChanged bool
+		wantDiff    []string
+	}{
+		{
+			name:        "new file",
+			config:      map[string]interface{}{"pruning-enabled": true},
+			wantCreated: true,
+			wantChanged: true,
+		},
+		{
+			name:        "unchanged file",
+			existing:    "{\n  \"pruning-enabled\": true\n}\n",
+			config:      map[string]interface{}{"pruning-enabled": true},
+			wantChanged: false,
+		},
+		{
+			name:        "overwritten file",
+			existing:    "{\n  \"pruning-enabled\": false\n}\n",
+			config:      map[string]interface{}{"pruning-enabled": true},
+			wantChanged: true,
+			wantDiff: []string{
+				"-  \"pruning-enabled\": false\n",
+				"+  \"pruning-enabled\": true\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.existing != "" {
+				assert.NoError(t, afero.WriteFile(fs, path, []byte(test.existing), 0o600))
+			}
+
+			configs := NewConfigs(fs, testConfigsDir, testBackupDir)
+			diff, err := configs.Diff(configs.ChainConfig(chainID, test.config))
+			assert.NoError(t, err)
+			exists, err := afero.Exists(fs, path)
+			assert.NoError(t, err)
+			assert.Equal(t, !test.wantCreated, exists)
+
+			change, err := configs.WriteChainConfig(chainID, test.config)
+			assert.NoError(t, err)
+			assert.Equal(t, diff, change)
+			assert.Equal(t, path, change.Path)
+			assert.Equal(t, test.wantCreated, change.Created)
+			assert.Equal(t, test.wantChanged, change.Changed())
+			for _, line := range test.wantDiff {
+				assert.Contains(t, change.Diff, line)
+			}
+
+			bytes, err := afero.ReadFile(fs, path)
+			assert.NoError(t, err)
+			assert.Equal(t, "{\n  \"pruning-enabled\": true\n}\n", string(bytes))
+
+			backup, err := afero.ReadFile(fs, backupPath)
+			if test.wantDiff != nil {
+				assert.NoError(t, err)
+				assert.Equal(t, test.existing, string(backup))
+			} else {
+				assert.Error(t, err)
+			}
+
+			// the node only finds one config file per chain
+			matches, err := afero.Glob(fs, filepath.Join(filepath.Dir(path), "config.*"))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{path}, matches)
+		})
+	}
+}
+
+func TestConfigsWriteKeepsFirstBackup(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	configs := NewConfigs(fs, testConfigsDir, testBackupDir)
+	chainID := ids.ID{3}
+	path := configs.ChainConfig(chainID, nil).Path
+	const original = "{\n  \"pruning-enabled\": false\n}\n"
+	assert.NoError(t, afero.WriteFile(fs, path, []byte(original), 0o600))
+
+	_, err := configs.WriteChainConfig(chainID, map[string]interface{}{"pruning-enabled": true})
+	assert.NoError(t, err)
+	_, err = configs.WriteChainConfig(chainID, map[string]interface{}{"state-sync-enabled": true})
+	assert.NoError(t, err)
+
+	restored, err := configs.Restore(path)
+	assert.NoError(t, err)
+	assert.True(t, restored)
+	bytes, err := afero.ReadFile(fs, path)
+	assert.NoError(t, err)
+	assert.Equal(t, original, string(bytes))
+}
+
+func TestConfigsPaths(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	configs := NewConfigs(fs, "/configs", testBackupDir)
+	id := ids.ID{4}
+
+	change, err := configs.WriteSubnetConfig(id, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/configs", "subnets", id.String()+".json"), change.Path)
+
+	change, err = configs.WriteChainUpgrade(id, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/configs", "chains", id.String(), "upgrade.json"), change.Path)
+}
+
+func TestConfigsRestore(t *testing.T) {
+	chainID := ids.ID{3}
+
+	tests := []struct {
+		name         string
+		existing     string
+		wantRestored bool
+	}{
+		{
+			name: "created file is deleted",
+		},
+		{
+			name:         "overwritten file is restored",
+			existing:     "{\n  \"pruning-enabled\": false\n}\n",
+			wantRestored: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			configs := NewConfigs(fs, testConfigsDir, testBackupDir)
+			path := configs.ChainConfig(chainID, nil).Path
+			if test.existing != "" {
+				assert.NoError(t, afero.WriteFile(fs, path, []byte(test.existing), 0o600))
+			}
+
+			_, err := configs.WriteChainConfig(chainID, map[string]interface{}{"pruning-enabled": true})
+			assert.NoError(t, err)
+
+			restored, err := configs.Restore(path)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantRestored, restored)
+
+			bytes, err := afero.ReadFile(fs, path)
+			if test.existing == "" {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.existing, string(bytes))
+			}
+
+			exists, err := afero.Exists(fs, filepath.Join(testBackupDir, "chains", chainID.String(), "config.json"))
+			assert.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Keys of the node config the apm reads settings from. They're the node's
+// flag names.
+const (
+	PluginDirKey       = "plugin-dir"
+	BuildDirKey        = "build-dir"
+	DataDirKey         = "data-dir"
+	HTTPHostKey        = "http-host"
+	HTTPPortKey        = "http-port"
+	HTTPTLSEnabledKey  = "http-tls-enabled"
+	AdminAPIEnabledKey = "api-admin-enabled"
+	ChainConfigDirKey  = "chain-config-dir"
+	SubnetConfigDirKey = "subnet-config-dir"
+
+	defaultHTTPHost = "127.0.0.1"
+	defaultHTTPPort = 9650
+	pluginsDir      = "plugins"
+)
+
+// defaultDataDir is where the node keeps its data if its config doesn't say.
+var defaultDataDir = filepath.Join("$HOME", ".metalgo")
+
+// Settings are what the apm needs to know about a node, read from its config
+// file. Settings the file doesn't have are the node's defaults.
+type Settings struct {
+	// PluginDir is where the node loads vms from. It's empty if the node
+	// config doesn't say, since the node's default depends on where its
+	// binary is.
+	PluginDir string
+	DataDir   string
+	HTTPHost  string
+	HTTPPort  uint64
+	HTTPTLS   bool
+	// AdminAPIEnabled is false by default, in which case the node can't be
+	// asked to load vms.
+	AdminAPIEnabled bool
+	// ConfigsDir is where the node reads subnet configs (subnets/) and chain
+	// configs (chains/) from. It's empty if they're in unrelated
+	// directories.
+	ConfigsDir string
+}
+
+// AdminAPIEndpoint returns the url of the node's admin api.
+func (s Settings) AdminAPIEndpoint() string {
+	scheme := "http"
+	if s.HTTPTLS {
+		scheme = "https"
+	}
+
+	host := s.HTTPHost
+	// a node listening on every interface is reached locally
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = defaultHTTPHost
+	}
+
+	return fmt.Sprintf("%s://%s/ext/admin", scheme, net.JoinHostPort(host, strconv.FormatUint(s.HTTPPort, 10)))
+}
+
+// Settings reads the node's settings from its config file.
+func (c *Config) Settings() (Settings, error) {
+	values, err := c.read()
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings := Settings{
+		DataDir:  expandPath(defaultDataDir),
+		HTTPHost: defaultHTTPHost,
+		HTTPPort: defaultHTTPPort,
+	}
+	var (
+		buildDir        string
+		chainConfigDir  string
+		subnetConfigDir string
+	)
+	errs := []error{
+		readString(values, DataDirKey, &settings.DataDir),
+		readString(values, PluginDirKey, &settings.PluginDir),
+		readString(values, BuildDirKey, &buildDir),
+		readString(values, HTTPHostKey, &settings.HTTPHost),
+		readUint(values, HTTPPortKey, &settings.HTTPPort),
+		readBool(values, HTTPTLSEnabledKey, &settings.HTTPTLS),
+		readBool(values, AdminAPIEnabledKey, &settings.AdminAPIEnabled),
+		readString(values, ChainConfigDirKey, &chainConfigDir),
+		readString(values, SubnetConfigDirKey, &subnetConfigDir),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return Settings{}, fmt.Errorf("invalid node config %s: %w", c.path, err)
+		}
+	}
+
+	settings.DataDir = expandPath(settings.DataDir)
+	settings.PluginDir = expandPath(settings.PluginDir)
+	if settings.PluginDir == "" && buildDir != "" {
+		settings.PluginDir = filepath.Join(expandPath(buildDir), pluginsDir)
+	}
+
+	settings.ConfigsDir = configsDir(
+		filepath.Join(settings.DataDir, "configs"),
+		expandPath(chainConfigDir),
+		expandPath(subnetConfigDir),
+	)
+
+	return settings, nil
+}
+
+// configsDir returns the directory that has both the node's chain config
+// directory as chains/ and its subnet config directory as subnets/, which is
+// how the apm writes configs. It's empty if there's no such directory.
+func configsDir(defaultDir string, chainConfigDir string, subnetConfigDir string) string {
+	if chainConfigDir == "" {
+		chainConfigDir = filepath.Join(defaultDir, chainsDir)
+	}
+	if subnetConfigDir == "" {
+		subnetConfigDir = filepath.Join(defaultDir, subnetsDir)
+	}
+
+	dir := filepath.Dir(chainConfigDir)
+	if filepath.Base(chainConfigDir) != chainsDir || filepath.Base(subnetConfigDir) != subnetsDir || filepath.Dir(subnetConfigDir) != dir {
+		return ""
+	}
+
+	return dir
+}
+
+// expandPath expands environment variables and a leading ~ in [path].
+func expandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = filepath.Join("$HOME", path[1:])
+	}
+
+	return os.ExpandEnv(path)
+}
+
+func readString(values map[string]json.RawMessage, key string, dst *string) error {
+	raw, ok := values[key]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("%s must be a string: %w", key, err)
+	}
+
+	return nil
+}
+
+// readUint accepts numbers and numeric strings, like the node does.
+func readUint(values map[string]json.RawMessage, key string, dst *uint64) error {
+	raw, ok := values[key]
+	if !ok {
+		return nil
+	}
+
+	value, err := strconv.ParseUint(strings.Trim(string(raw), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a number: %w", key, err)
+	}
+	*dst = value
+	return nil
+}
+
+// readBool accepts booleans and boolean strings, like the node does.
+func readBool(values map[string]json.RawMessage, key string, dst *bool) error {
+	raw, ok := values[key]
+	if !ok {
+		return nil
+	}
+
+	value, err := strconv.ParseBool(strings.Trim(string(raw), `"`))
+	if err != nil {
+		return fmt.Errorf("%s must be a boolean: %w", key, err)
+	}
+	*dst = value
+	return nil
+}
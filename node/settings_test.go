@@ -0,0 +1,106 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSettings(t *testing.T) {
+	t.Setenv("HOME", "/home/node")
+
+	tests := []struct {
+		name         string
+		config       string
+		want         Settings
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{
+			name:   "defaults",
+			config: `{}`,
+			want: Settings{
+				DataDir:    "/home/node/.metalgo",
+				HTTPHost:   "127.0.0.1",
+				HTTPPort:   9650,
+				ConfigsDir: "/home/node/.metalgo/configs",
+			},
+			wantEndpoint: "http://127.0.0.1:9650/ext/admin",
+		},
+		{
+			name: "configured",
+			config: `{
+				"data-dir": "/var/lib/metalgo",
+				"plugin-dir": "~/plugins",
+				"http-host": "0.0.0.0",
+				"http-port": "9660",
+				"http-tls-enabled": true,
+				"api-admin-enabled": "true"
+			}`,
+			want: Settings{
+				PluginDir:       "/home/node/plugins",
+				DataDir:         "/var/lib/metalgo",
+				HTTPHost:        "0.0.0.0",
+				HTTPPort:        9660,
+				HTTPTLS:         true,
+				AdminAPIEnabled: true,
+				ConfigsDir:      "/var/lib/metalgo/configs",
+			},
+			wantEndpoint: "https://127.0.0.1:9660/ext/admin",
+		},
+		{
+			name: "plugins in build dir",
+			config: `{
+				"build-dir": "/opt/metalgo/build",
+				"http-host": "::1",
+				"chain-config-dir": "/etc/metalgo/chains",
+				"subnet-config-dir": "/etc/metalgo/subnets"
+			}`,
+			want: Settings{
+				PluginDir:  "/opt/metalgo/build/plugins",
+				DataDir:    "/home/node/.metalgo",
+				HTTPHost:   "::1",
+				HTTPPort:   9650,
+				ConfigsDir: "/etc/metalgo",
+			},
+			wantEndpoint: "http://[::1]:9650/ext/admin",
+		},
+		{
+			name: "config dirs apart",
+			config: `{
+				"chain-config-dir": "/etc/metalgo/chain-configs"
+			}`,
+			want: Settings{
+				DataDir:  "/home/node/.metalgo",
+				HTTPHost: "127.0.0.1",
+				HTTPPort: 9650,
+			},
+			wantEndpoint: "http://127.0.0.1:9650/ext/admin",
+		},
+		{
+			name:    "invalid port",
+			config:  `{"http-port": "http"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			assert.NoError(t, afero.WriteFile(fs, path, []byte(test.config), 0o600))
+
+			settings, err := NewConfig(fs, path).Settings()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, settings)
+			assert.Equal(t, test.wantEndpoint, settings.AdminAPIEndpoint())
+		})
+	}
+}
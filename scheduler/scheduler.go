@@ -0,0 +1,176 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var _ AutoUpgrader = &apm.APM{}
+
+// AutoUpgrader is the part of the apm the scheduler runs.
+type AutoUpgrader interface {
+	AutoUpgrade(policy apm.AutoUpgradePolicy) (apm.Result, error)
+	AutoUpgradeState() (storage.AutoUpgradeState, error)
+}
+
+type Config struct {
+	APM    AutoUpgrader
+	Policy apm.AutoUpgradePolicy
+	// Interval is the time between the start of two runs.
+	Interval time.Duration
+	// Windows are when runs may start. Empty means any time.
+	Windows []Window
+	// Jitter is the most a run is randomly delayed by, so a fleet of nodes
+	// with the same schedule doesn't upgrade at the same time. It should be
+	// shorter than the windows.
+	Jitter time.Duration
+	Log    logging.Logger
+}
+
+func New(config Config) *Scheduler {
+	return &Scheduler{
+		apm:      config.APM,
+		policy:   config.Policy,
+		interval: config.Interval,
+		windows:  config.Windows,
+		jitter:   config.Jitter,
+		log:      config.Log,
+		now:      time.Now,
+		sleep:    sleep,
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())), // #nosec G404 jitter doesn't need to be unpredictable
+	}
+}
+
+// Scheduler periodically auto-upgrades the apm under a policy.
+type Scheduler struct {
+	apm      AutoUpgrader
+	policy   apm.AutoUpgradePolicy
+	interval time.Duration
+	windows  []Window
+	jitter   time.Duration
+	log      logging.Logger
+
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	random *rand.Rand
+
+	// lastRun is when this scheduler last auto-upgraded. The apm's state
+	// has it too, unless it couldn't be saved.
+	lastRun time.Time
+}
+
+// Run auto-upgrades every interval, inside the maintenance windows, until
+// [ctx] is done. A failed run is retried at the next interval.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		state, err := s.apm.AutoUpgradeState()
+		if err != nil {
+			return err
+		}
+
+		lastRun := state.LastRun
+		if s.lastRun.After(lastRun) {
+			lastRun = s.lastRun
+		}
+
+		next := lastRun.Add(s.interval)
+		if now := s.now(); next.Before(now) {
+			next = now
+		}
+		next = s.nextWindow(next)
+
+		s.log.Info("Next auto-upgrade at %s.", next.Format(time.RFC3339))
+		if err := s.sleep(ctx, next.Sub(s.now())); err != nil {
+			return err
+		}
+
+		if _, _, err := s.RunOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.log.Error("Auto-upgrade failed: %s", err)
+		}
+	}
+}
+
+// RunOnce auto-upgrades after the jitter, if it's inside a maintenance
+// window. It returns false if the upgrade didn't run.
+func (s *Scheduler) RunOnce(ctx context.Context) (apm.Result, bool, error) {
+	if !s.inWindow(s.now()) {
+		s.log.Info("Not auto-upgrading outside of the maintenance windows.")
+		return apm.Result{}, false, nil
+	}
+
+	if s.jitter > 0 {
+		delay := time.Duration(s.random.Int63n(int64(s.jitter)))
+		s.log.Info("Waiting %s before auto-upgrading...", delay.Round(time.Second))
+		if err := s.sleep(ctx, delay); err != nil {
+			return apm.Result{}, false, err
+		}
+
+		// the jitter might have pushed us out of the window
+		if !s.inWindow(s.now()) {
+			s.log.Info("Not auto-upgrading since the maintenance window ended.")
+			return apm.Result{}, false, nil
+		}
+	}
+
+	s.lastRun = s.now()
+	result, err := s.apm.AutoUpgrade(s.policy)
+	return result, true, err
+}
+
+func (s *Scheduler) inWindow(t time.Time) bool {
+	if len(s.windows) == 0 {
+		return true
+	}
+
+	for _, window := range s.windows {
+		if window.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextWindow returns the earliest time at or after [t] that's inside a
+// maintenance window.
+func (s *Scheduler) nextWindow(t time.Time) time.Time {
+	if len(s.windows) == 0 {
+		return t
+	}
+
+	next := s.windows[0].next(t)
+	for _, window := range s.windows[1:] {
+		if candidate := window.next(t); candidate.Before(next) {
+			next = candidate
+		}
+	}
+
+	return next
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/workflow"
+)
+
+var _ AutoUpgrader = &fakeAPM{}
+
+type fakeAPM struct {
+	state    storage.AutoUpgradeState
+	policies []apm.AutoUpgradePolicy
+	err      error
+}
+
+func (f *fakeAPM) AutoUpgrade(policy apm.AutoUpgradePolicy) (apm.Result, error) {
+	f.policies = append(f.policies, policy)
+	return apm.Result{}, f.err
+}
+
+func (f *fakeAPM) AutoUpgradeState() (storage.AutoUpgradeState, error) {
+	return f.state, nil
+}
+
+func TestRunOnce(t *testing.T) {
+	policy := apm.AutoUpgradePolicy{MaxLevel: workflow.Patch}
+	window := Window{Start: 2 * time.Hour, End: 3 * time.Hour}
+
+	tests := []struct {
+		name    string
+		windows []Window
+		jitter  time.Duration
+		now     []time.Time
+		wantRan bool
+	}{
+		{
+			name:    "no windows",
+			now:     []time.Time{at(4, 12, 0)},
+			wantRan: true,
+		},
+		{
+			name:    "inside window",
+			windows: []Window{window},
+			now:     []time.Time{at(4, 2, 0)},
+			wantRan: true,
+		},
+		{
+			name:    "outside window",
+			windows: []Window{window},
+			now:     []time.Time{at(4, 12, 0)},
+		},
+		{
+			name:    "jitter pushes out of window",
+			windows: []Window{window},
+			jitter:  time.Hour,
+			now:     []time.Time{at(4, 2, 59), at(4, 3, 30)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeAPM{}
+			s := New(Config{
+				APM:     fake,
+				Policy:  policy,
+				Windows: test.windows,
+				Jitter:  test.jitter,
+				Log:     logging.NoLog{},
+			})
+
+			now := test.now
+			s.now = func() time.Time {
+				next := now[0]
+				if len(now) > 1 {
+					now = now[1:]
+				}
+				return next
+			}
+			s.sleep = func(context.Context, time.Duration) error { return nil }
+			s.random = rand.New(rand.NewSource(0)) // #nosec G404
+
+			_, ran, err := s.RunOnce(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantRan, ran)
+			if test.wantRan {
+				assert.Equal(t, []apm.AutoUpgradePolicy{policy}, fake.policies)
+			} else {
+				assert.Empty(t, fake.policies)
+			}
+		})
+	}
+}
+
+func TestRunWaitsForInterval(t *testing.T) {
+	lastRun := at(4, 1, 0)
+	fake := &fakeAPM{state: storage.AutoUpgradeState{LastRun: lastRun}}
+
+	s := New(Config{
+		APM:      fake,
+		Interval: 24 * time.Hour,
+		Windows:  []Window{{Start: 2 * time.Hour, End: 3 * time.Hour}},
+		Log:      logging.NoLog{},
+	})
+	s.now = func() time.Time { return at(4, 12, 0) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var slept time.Duration
+	s.sleep = func(_ context.Context, d time.Duration) error {
+		slept = d
+		cancel()
+		return context.Canceled
+	}
+
+	assert.ErrorIs(t, s.Run(ctx), context.Canceled)
+	// the interval ends at 01:00 the next day, and the window opens at 02:00
+	assert.Equal(t, at(5, 2, 0).Sub(at(4, 12, 0)), slept)
+	assert.Empty(t, fake.policies)
+}
+
+func TestRunWaitsIfStateIsntSaved(t *testing.T) {
+	// the state can't be saved, so it never records a run
+	fake := &fakeAPM{err: errors.New("failed to save the auto-upgrade state")}
+
+	s := New(Config{
+		APM:      fake,
+		Interval: 24 * time.Hour,
+		Log:      logging.NoLog{},
+	})
+	s.now = func() time.Time { return at(4, 12, 0) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var slept []time.Duration
+	s.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		if len(slept) == 2 {
+			cancel()
+			return context.Canceled
+		}
+		return nil
+	}
+
+	assert.ErrorIs(t, s.Run(ctx), context.Canceled)
+	assert.Len(t, fake.policies, 1)
+	assert.Equal(t, []time.Duration{0, 24 * time.Hour}, slept)
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	day        = 24 * time.Hour
+	timeLayout = "15:04"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a recurring maintenance window in local time. A window that ends
+// before it starts runs past midnight, into the next day.
+type Window struct {
+	// Days the window starts on. Empty means every day.
+	Days []time.Weekday
+	// Start and End are offsets from midnight.
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseWindow parses a window like "02:00-04:00" (every day) or
+// "sat,sun 22:00-02:00".
+func ParseWindow(window string) (Window, error) {
+	result := Window{}
+
+	fields := strings.Fields(window)
+	switch len(fields) {
+	case 1:
+	case 2:
+		for _, name := range strings.Split(fields[0], ",") {
+			weekday, ok := weekdays[strings.ToLower(name)]
+			if !ok {
+				return Window{}, fmt.Errorf("unknown day %s in window %s", name, window)
+			}
+			result.Days = append(result.Days, weekday)
+		}
+	default:
+		return Window{}, fmt.Errorf("window %s must look like [days] HH:MM-HH:MM", window)
+	}
+
+	times := strings.Split(fields[len(fields)-1], "-")
+	if len(times) != 2 {
+		return Window{}, fmt.Errorf("window %s must look like [days] HH:MM-HH:MM", window)
+	}
+
+	var err error
+	if result.Start, err = parseTimeOfDay(times[0]); err != nil {
+		return Window{}, err
+	}
+	if result.End, err = parseTimeOfDay(times[1]); err != nil {
+		return Window{}, err
+	}
+
+	return result, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("%s isn't a time of day (HH:MM)", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains returns true if [t] is inside the window.
+func (w Window) Contains(t time.Time) bool {
+	midnight := truncateToDay(t)
+	offset := t.Sub(midnight)
+
+	if w.End > w.Start {
+		return w.startsOn(t.Weekday()) && offset >= w.Start && offset < w.End
+	}
+
+	// the window runs past midnight, so it might have started yesterday
+	yesterday := midnight.AddDate(0, 0, -1).Weekday()
+	return (w.startsOn(t.Weekday()) && offset >= w.Start) ||
+		(w.startsOn(yesterday) && offset < w.End)
+}
+
+// next returns the earliest time at or after [t] that's inside the window.
+func (w Window) next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	midnight := truncateToDay(t)
+	for i := 0; i <= 7; i++ {
+		date := midnight.AddDate(0, 0, i)
+		start := date.Add(w.Start)
+		if w.startsOn(date.Weekday()) && !start.Before(t) {
+			return start
+		}
+	}
+
+	// unreachable, since every window starts at least once a week
+	return t
+}
+
+func (w Window) startsOn(weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+
+	for _, d := range w.Days {
+		if d == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w Window) String() string {
+	days := make([]string, 0, len(w.Days))
+	for _, d := range w.Days {
+		days = append(days, strings.ToLower(d.String()[:3]))
+	}
+
+	midnight := time.Time{}
+	times := fmt.Sprintf("%s-%s", midnight.Add(w.Start).Format(timeLayout), midnight.Add(w.End).Format(timeLayout))
+	if len(days) == 0 {
+		return times
+	}
+
+	return fmt.Sprintf("%s %s", strings.Join(days, ","), times)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	year, month, date := t.Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 2022-06-04 is a saturday
+func at(day int, hour int, minute int) time.Time {
+	return time.Date(2022, time.June, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		window  string
+		want    Window
+		wantErr bool
+	}{
+		{
+			window: "02:00-04:30",
+			want:   Window{Start: 2 * time.Hour, End: 4*time.Hour + 30*time.Minute},
+		},
+		{
+			window: "Sat,sun 22:00-02:00",
+			want:   Window{Days: []time.Weekday{time.Saturday, time.Sunday}, Start: 22 * time.Hour, End: 2 * time.Hour},
+		},
+		{
+			window:  "someday 02:00-04:00",
+			wantErr: true,
+		},
+		{
+			window:  "02:00",
+			wantErr: true,
+		},
+		{
+			window:  "2am-4am",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.window, func(t *testing.T) {
+			window, err := ParseWindow(test.window)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, window)
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	daily := Window{Start: 2 * time.Hour, End: 4 * time.Hour}
+	weekendNights := Window{Days: []time.Weekday{time.Saturday}, Start: 22 * time.Hour, End: 2 * time.Hour}
+
+	tests := []struct {
+		name         string
+		window       Window
+		t            time.Time
+		wantContains bool
+		wantNext     time.Time
+	}{
+		{
+			name:         "inside",
+			window:       daily,
+			t:            at(4, 3, 0),
+			wantContains: true,
+			wantNext:     at(4, 3, 0),
+		},
+		{
+			name:     "before",
+			window:   daily,
+			t:        at(4, 1, 0),
+			wantNext: at(4, 2, 0),
+		},
+		{
+			name:     "end is exclusive",
+			window:   daily,
+			t:        at(4, 4, 0),
+			wantNext: at(5, 2, 0),
+		},
+		{
+			name:         "past midnight on the start day",
+			window:       weekendNights,
+			t:            at(4, 23, 0),
+			wantContains: true,
+			wantNext:     at(4, 23, 0),
+		},
+		{
+			name:         "past midnight on the next day",
+			window:       weekendNights,
+			t:            at(5, 1, 0),
+			wantContains: true,
+			wantNext:     at(5, 1, 0),
+		},
+		{
+			name:     "next week",
+			window:   weekendNights,
+			t:        at(5, 3, 0),
+			wantNext: at(11, 22, 0),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantContains, test.window.Contains(test.t))
+			assert.Equal(t, test.wantNext, test.window.next(test.t))
+		})
+	}
+}
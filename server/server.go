@@ -0,0 +1,340 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/logging"
+)
+
+const (
+	unixScheme = "unix://"
+	// socketUmask leaves the socket only usable by its owner
+	socketUmask        = 0o177
+	staleSocketTimeout = time.Second
+
+	ndjsonContentType = "application/x-ndjson"
+	jsonContentType   = "application/json"
+
+	// maxRequestSize is far more than a Request needs
+	maxRequestSize = 1 << 16
+)
+
+var (
+	errLoopbackOnly = errors.New("the management api may only listen on a unix socket or a loopback address")
+	errInUse        = errors.New("another server is listening on the socket")
+	errUnknownHost  = errors.New("unknown host")
+)
+
+// Request is the body of a request to an endpoint that modifies the apm's
+// state.
+type Request struct {
+	// VM is the vm alias for install, uninstall and upgrade. It's optional
+	// for upgrade, in which case every installed vm is upgraded.
+	VM string `json:"vm,omitempty"`
+	// Subnet is the subnet alias for join-subnet and leave-subnet.
+	Subnet string `json:"subnet,omitempty"`
+}
+
+// Response is the last line of a streamed response, after the log lines
+// reporting its progress.
+type Response struct {
+	Result *apm.Result `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ErrorResponse is returned by endpoints that don't stream their response if
+// the request failed.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type Config struct {
+	APM *apm.APM
+	// Log is where the daemon logs to. It also receives the progress of every
+	// request.
+	Log logging.Logger
+	// Progress must be the logger the APM was created with, so the progress
+	// of each request can be streamed back to its caller.
+	Progress *logging.Switch
+	// Gatherer, if set, is exposed on /metrics.
+	Gatherer prometheus.Gatherer
+	// Address is what the server listens on, as given to Listen. Requests to
+	// a tcp address must name it as their host, so a web page that was
+	// pointed at a loopback address through DNS rebinding can't use the api.
+	Address string
+}
+
+func New(config Config) *Server {
+	s := &Server{
+		apm:      config.APM,
+		log:      config.Log,
+		progress: config.Progress,
+		hosts:    allowedHosts(config.Address),
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/v1/install", s.mutation(func(request Request) (apm.Result, error) {
+		return s.apm.Install(request.VM)
+	}))
+	s.mux.HandleFunc("/v1/uninstall", s.mutation(func(request Request) (apm.Result, error) {
+		return s.apm.Uninstall(request.VM)
+	}))
+	s.mux.HandleFunc("/v1/upgrade", s.mutation(func(request Request) (apm.Result, error) {
+		return s.apm.Upgrade(request.VM)
+	}))
+	s.mux.HandleFunc("/v1/update", s.mutation(func(Request) (apm.Result, error) {
+		return s.apm.Update()
+	}))
+	s.mux.HandleFunc("/v1/join-subnet", s.mutation(func(request Request) (apm.Result, error) {
+		return s.apm.JoinSubnet(request.Subnet)
+	}))
+	s.mux.HandleFunc("/v1/leave-subnet", s.mutation(func(request Request) (apm.Result, error) {
+		return s.apm.LeaveSubnet(request.Subnet)
+	}))
+	s.mux.HandleFunc("/v1/repositories", s.query(func(*http.Request) (interface{}, error) {
+		return s.apm.ListRepositories()
+	}))
+	s.mux.HandleFunc("/v1/info", s.query(func(r *http.Request) (interface{}, error) {
+		return s.apm.Info(r.URL.Query().Get("vm"))
+	}))
+	if config.Gatherer != nil {
+		s.mux.Handle("/metrics", promhttp.HandlerFor(config.Gatherer, promhttp.HandlerOpts{}))
+	}
+
+	return s
+}
+
+// Server exposes the apm over HTTP/JSON. Requests are handled one at a time,
+// since the apm isn't safe for concurrent use.
+type Server struct {
+	apm      *apm.APM
+	log      logging.Logger
+	progress *logging.Switch
+	// hosts are the hosts requests may be sent to. Any host is allowed if
+	// it's nil.
+	hosts map[string]struct{}
+	mux   *http.ServeMux
+
+	lock sync.Mutex
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.hosts[strings.ToLower(r.Host)]; s.hosts != nil && !ok {
+		writeError(w, http.StatusForbidden, fmt.Errorf("%w: %s", errUnknownHost, r.Host))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// allowedHosts returns the hosts requests to [address] may name, or nil if
+// it's a unix socket, where the host doesn't mean anything.
+func allowedHosts(address string) map[string]struct{} {
+	if address == "" || strings.HasPrefix(address, unixScheme) {
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// nothing can be reached there
+		return map[string]struct{}{}
+	}
+	return map[string]struct{}{
+		strings.ToLower(net.JoinHostPort(host, port)): {},
+		net.JoinHostPort("localhost", port):           {},
+	}
+}
+
+// mutation handles a POST that modifies the apm's state. The response is
+// newline-delimited JSON: the request's log lines as they happen, followed
+// by a Response.
+func (s *Server) mutation(call func(Request) (apm.Result, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires %s", r.URL.Path, http.MethodPost))
+			return
+		}
+
+		// browsers send some cross-origin posts without asking first, but
+		// never with a json body
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != jsonContentType {
+			writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("%s requires a %s body", r.URL.Path, jsonContentType))
+			return
+		}
+
+		request := Request{}
+		body := http.MaxBytesReader(w, r.Body, maxRequestSize)
+		if err := json.NewDecoder(body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		w.Header().Set("Content-Type", ndjsonContentType)
+		w.WriteHeader(http.StatusOK)
+
+		stream := &flushWriter{writer: w}
+		if flusher, ok := w.(http.Flusher); ok {
+			stream.flusher = flusher
+		}
+
+		s.progress.Set(logging.NewMulti(s.log, logging.New(logging.Config{
+			Writer: stream,
+			Level:  logging.Info,
+			Format: logging.JSON,
+		})))
+		defer s.progress.Set(s.log)
+
+		s.log.Info("Handling %s", r.URL.Path)
+		response := Response{}
+		result, err := call(request)
+		if err != nil {
+			s.log.Error("%s failed: %s", r.URL.Path, err)
+			response.Error = err.Error()
+		}
+		response.Result = &result
+
+		if err := json.NewEncoder(stream).Encode(response); err != nil {
+			s.log.Debug("Failed to write response to %s: %s", r.URL.Path, err)
+		}
+	}
+}
+
+// query handles a GET that reads the apm's state.
+func (s *Server) query(call func(*http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires %s", r.URL.Path, http.MethodGet))
+			return
+		}
+
+		s.lock.Lock()
+		result, err := call(r)
+		s.lock.Unlock()
+
+		if err != nil {
+			writeError(w, statusCode(err), err)
+			return
+		}
+
+		w.Header().Set("Content-Type", jsonContentType)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			s.log.Debug("Failed to write response to %s: %s", r.URL.Path, err)
+		}
+	}
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, apm.ErrUnknownAlias):
+		return http.StatusNotFound
+	case errors.Is(err, apm.ErrAmbiguousAlias):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
+
+// Listen listens on [address], which is either unix:// followed by the path
+// of a socket, or a loopback host:port.
+func Listen(address string) (net.Listener, error) {
+	if strings.HasPrefix(address, unixScheme) {
+		path := strings.TrimPrefix(address, unixScheme)
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+
+		// the api isn't authenticated, so only the apm's user may use it.
+		// The socket is created with these permissions so it's never open
+		// to anyone else.
+		var (
+			listener net.Listener
+			err      error
+		)
+		withUmask(socketUmask, func() {
+			listener, err = net.Listen("unix", path)
+		})
+		return listener, err
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return nil, fmt.Errorf("%w: %s", errLoopbackOnly, address)
+		}
+	}
+
+	return net.Listen("tcp", address)
+}
+
+// removeStaleSocket removes the socket at [path] if it was left behind by a
+// server that's no longer running.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		// listening fails with a clearer error than we could give
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, staleSocketTimeout)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%w: %s", errInUse, path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// flushWriter flushes every write so progress reaches the caller as it
+// happens.
+type flushWriter struct {
+	writer  io.Writer
+	flusher http.Flusher
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.writer.Write(p)
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+
+	return n, err
+}
@@ -0,0 +1,246 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/apm"
+	"github.com/shubhamdubey02/apm/logging"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	progress := logging.NewSwitch(logging.NoLog{})
+
+	a, err := apm.New(
+		apm.Config{
+			Directory: t.TempDir(),
+			PluginDir: t.TempDir(),
+			Fs:        afero.NewOsFs(),
+		},
+		apm.WithLogger(progress),
+		apm.WithoutBootstrap(),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = a.Close() })
+
+	s := httptest.NewUnstartedServer(nil)
+	s.Config.Handler = New(Config{
+		APM:      a,
+		Log:      logging.NoLog{},
+		Progress: progress,
+		Address:  s.Listener.Addr().String(),
+	})
+	s.Start()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		host        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    func(t *testing.T, body string)
+	}{
+		{
+			name:       "list repositories",
+			method:     http.MethodGet,
+			path:       "/v1/repositories",
+			wantStatus: http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				assert.Equal(t, "[]\n", body)
+			},
+		},
+		{
+			name:       "info for unknown alias",
+			method:     http.MethodGet,
+			path:       "/v1/info?vm=unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody: func(t *testing.T, body string) {
+				response := ErrorResponse{}
+				assert.NoError(t, json.Unmarshal([]byte(body), &response))
+				assert.Contains(t, response.Error, "unknown alias")
+			},
+		},
+		{
+			name:        "install for unknown alias",
+			method:      http.MethodPost,
+			path:        "/v1/install",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"vm": "unknown"}`,
+			wantStatus:  http.StatusOK,
+			wantBody: func(t *testing.T, body string) {
+				// the response is always the last line of the stream
+				var last string
+				scanner := bufio.NewScanner(strings.NewReader(body))
+				for scanner.Scan() {
+					last = scanner.Text()
+				}
+
+				response := Response{}
+				assert.NoError(t, json.Unmarshal([]byte(last), &response))
+				assert.Contains(t, response.Error, "unknown alias")
+				assert.NotNil(t, response.Result)
+			},
+		},
+		{
+			name:       "mutation requires post",
+			method:     http.MethodGet,
+			path:       "/v1/install",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "malformed request",
+			method:      http.MethodPost,
+			path:        "/v1/install",
+			contentType: "application/json",
+			body:        `{"vm":`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "mutation requires json",
+			method:      http.MethodPost,
+			path:        "/v1/install",
+			contentType: "text/plain",
+			body:        `{"vm": "unknown"}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "oversized request",
+			method:      http.MethodPost,
+			path:        "/v1/install",
+			contentType: "application/json",
+			body:        `{"vm": "` + strings.Repeat("a", maxRequestSize) + `"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:       "unknown host",
+			method:     http.MethodGet,
+			path:       "/v1/repositories",
+			host:       "attacker.example.com",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "localhost",
+			method:     http.MethodGet,
+			path:       "/v1/repositories",
+			host:       "localhost",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			request, err := http.NewRequest(test.method, s.URL+test.path, strings.NewReader(test.body))
+			assert.NoError(t, err)
+			if test.host != "" {
+				_, port, err := net.SplitHostPort(request.Host)
+				assert.NoError(t, err)
+				request.Host = net.JoinHostPort(test.host, port)
+			}
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+
+			response, err := s.Client().Do(request)
+			assert.NoError(t, err)
+			defer response.Body.Close()
+
+			assert.Equal(t, test.wantStatus, response.StatusCode)
+			if test.wantBody == nil {
+				return
+			}
+
+			body := &strings.Builder{}
+			_, err = bufio.NewReader(response.Body).WriteTo(body)
+			assert.NoError(t, err)
+			test.wantBody(t, body.String())
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{
+			name:    "loopback",
+			address: "127.0.0.1:0",
+		},
+		{
+			name:    "localhost",
+			address: "localhost:0",
+		},
+		{
+			name:    "all interfaces",
+			address: "0.0.0.0:0",
+			wantErr: true,
+		},
+		{
+			name:    "unix socket",
+			address: "unix://" + t.TempDir() + "/apm.sock",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listener, err := Listen(test.address)
+			if test.wantErr {
+				assert.ErrorIs(t, err, errLoopbackOnly)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NoError(t, listener.Close())
+		})
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apm.sock")
+	address := "unix://" + path
+
+	listener, err := Listen(address)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	// a server is still using the socket
+	_, err = Listen(address)
+	assert.ErrorIs(t, err, errInUse)
+
+	// the server crashed without removing its socket
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, listener.Close())
+
+	listener, err = Listen(address)
+	assert.NoError(t, err)
+	assert.NoError(t, listener.Close())
+}
@@ -0,0 +1,16 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !windows
+
+package server
+
+import "syscall"
+
+// withUmask runs [f] with the process' umask set to [mask].
+func withUmask(mask int, f func()) {
+	previous := syscall.Umask(mask)
+	defer syscall.Umask(previous)
+
+	f()
+}
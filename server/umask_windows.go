@@ -0,0 +1,11 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build windows
+
+package server
+
+// withUmask runs [f]. Windows has no umask.
+func withUmask(_ int, f func()) {
+	f()
+}
@@ -0,0 +1,20 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"time"
+)
+
+// AutoUpgradeStateKey is the key the auto-upgrade state is stored under.
+var AutoUpgradeStateKey = []byte("state")
+
+// AutoUpgradeState is what the last scheduled auto-upgrade did.
+type AutoUpgradeState struct {
+	LastRun     time.Time `yaml:"lastRun" json:"lastRun"`
+	LastSuccess time.Time `yaml:"lastSuccess,omitempty" json:"lastSuccess,omitempty"`
+	// Upgraded is the number of vms the last run upgraded.
+	Upgraded int    `yaml:"upgraded" json:"upgraded"`
+	Error    string `yaml:"error,omitempty" json:"error,omitempty"`
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/MetalBlockchain/metalgo/version"
+)
+
+// OperationType is the kind of change an Operation made.
+type OperationType string
+
+const (
+	InstallOperation          OperationType = "install"
+	UninstallOperation        OperationType = "uninstall"
+	UpgradeOperation          OperationType = "upgrade"
+	DowngradeOperation        OperationType = "downgrade"
+	UpdateOperation           OperationType = "update"
+	AddRepositoryOperation    OperationType = "add-repository"
+	RemoveRepositoryOperation OperationType = "remove-repository"
+	JoinSubnetOperation       OperationType = "join-subnet"
+	LeaveSubnetOperation      OperationType = "leave-subnet"
+	AutoremoveOperation       OperationType = "autoremove"
+	MarkManualOperation       OperationType = "mark-manual"
+	LoadVMsOperation          OperationType = "load-vms"
+	HoldOperation             OperationType = "hold"
+	UnholdOperation           OperationType = "unhold"
+	SetRefOperation           OperationType = "set-ref"
+)
+
+// Outcome is the result of an Operation.
+type Outcome string
+
+const (
+	Succeeded Outcome = "succeeded"
+	Failed    Outcome = "failed"
+	Skipped   Outcome = "skipped"
+)
+
+// Operation is an entry in the append-only operation history.
+type Operation struct {
+	ID uint64 `yaml:"id" json:"id"`
+	// Parent is the ID of the operation that triggered this one, or 0 if this
+	// operation was requested directly.
+	Parent uint64        `yaml:"parent,omitempty" json:"parent,omitempty"`
+	Type   OperationType `yaml:"type" json:"type"`
+	// Name is the fully qualified name of the vm or subnet, or the alias of
+	// the repository this operation acted on.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	User string `yaml:"user,omitempty" json:"user,omitempty"`
+	// Profile is the profile the operation acted on. It's empty for the
+	// default profile.
+	Profile   string    `yaml:"profile,omitempty" json:"profile,omitempty"`
+	StartTime time.Time `yaml:"startTime" json:"startTime"`
+	EndTime   time.Time `yaml:"endTime" json:"endTime"`
+	Outcome   Outcome   `yaml:"outcome" json:"outcome"`
+	Error     string    `yaml:"error,omitempty" json:"error,omitempty"`
+	// Reason explains why an operation was skipped.
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+
+	PreviousVersion *version.Semantic `yaml:"previousVersion,omitempty" json:"previousVersion,omitempty"`
+	Version         *version.Semantic `yaml:"version,omitempty" json:"version,omitempty"`
+	PreviousCommit  string            `yaml:"previousCommit,omitempty" json:"previousCommit,omitempty"`
+	Commit          string            `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Checksum        string            `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+	// Path is where the vm binary was installed to or removed from.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Ref is what a repository was set to track.
+	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// ForcePushed is set if the repository's history was rewritten, so
+	// PreviousCommit isn't an ancestor of Commit.
+	ForcePushed bool `yaml:"forcePushed,omitempty" json:"forcePushed,omitempty"`
+}
+
+// OperationKey returns the history key for an operation id. Keys sort in the
+// same order as their ids.
+func OperationKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// OperationID returns the operation id of a history key, or 0 if it isn't
+// one.
+func OperationID(key []byte) uint64 {
+	if len(key) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(key)
+}
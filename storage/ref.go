@@ -0,0 +1,83 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrInvalidRef = errors.New("invalid ref")
+
+// RefType is what kind of ref a repository tracks.
+type RefType string
+
+const (
+	// BranchRef follows a branch.
+	BranchRef RefType = "branch"
+	// TagRef is pinned to a tag.
+	TagRef RefType = "tag"
+	// CommitRef is pinned to a commit.
+	CommitRef RefType = "commit"
+	// LatestTagRef follows the highest semantic version tag.
+	LatestTagRef RefType = "latest-tag"
+)
+
+// Ref is what a repository tracks.
+type Ref struct {
+	Type RefType `yaml:"type"`
+	// Name is the branch, tag or commit hash. It's empty for LatestTagRef.
+	Name string `yaml:"name,omitempty"`
+}
+
+func NewBranchRef(branch string) Ref {
+	return Ref{Type: BranchRef, Name: branch}
+}
+
+func NewTagRef(tag string) Ref {
+	return Ref{Type: TagRef, Name: tag}
+}
+
+func NewCommitRef(commit string) Ref {
+	return Ref{Type: CommitRef, Name: commit}
+}
+
+func NewLatestTagRef() Ref {
+	return Ref{Type: LatestTagRef}
+}
+
+// Verify returns an error if the ref can't be tracked.
+func (r Ref) Verify() error {
+	switch r.Type {
+	case BranchRef, TagRef:
+		if r.Name == "" {
+			return fmt.Errorf("%w: missing %s name", ErrInvalidRef, r.Type)
+		}
+	case CommitRef:
+		if !plumbing.IsHash(r.Name) {
+			return fmt.Errorf("%w: %q isn't a full commit hash", ErrInvalidRef, r.Name)
+		}
+	case LatestTagRef:
+		if r.Name != "" {
+			return fmt.Errorf("%w: %s doesn't take a name", ErrInvalidRef, r.Type)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRef, r.Type)
+	}
+	return nil
+}
+
+// Pinned returns true if the ref doesn't move on its own.
+func (r Ref) Pinned() bool {
+	return r.Type == TagRef || r.Type == CommitRef
+}
+
+func (r Ref) String() string {
+	if r.Type == LatestTagRef {
+		return string(r.Type)
+	}
+	return fmt.Sprintf("%s %s", r.Type, r.Name)
+}
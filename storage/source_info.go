@@ -12,10 +12,37 @@ import (
 
 // SourceInfo represents a repository, its source, and the last synced commit.
 type SourceInfo struct {
-	Alias  string                 `yaml:"alias"`
-	URL    string                 `yaml:"url"`
-	Commit plumbing.Hash          `yaml:"commit"`
-	Branch plumbing.ReferenceName `yaml:"branch"`
+	Alias  string        `yaml:"alias"`
+	URL    string        `yaml:"url"`
+	Commit plumbing.Hash `yaml:"commit"`
+	// Branch is the tracked branch. It's only set for branch refs.
+	Branch plumbing.ReferenceName `yaml:"branch,omitempty"`
+	// Ref is what the repository tracks. Repositories added before tags and
+	// commits could be tracked only have a Branch.
+	Ref Ref `yaml:"ref,omitempty"`
+	// Tag is the tag Commit was synced from, for latest tag refs.
+	Tag string `yaml:"tag,omitempty"`
+	// RefChanged is set if Ref changed since Commit was synced, so Commit
+	// may not be in its history.
+	RefChanged bool `yaml:"refChanged,omitempty"`
+}
+
+// TrackedRef returns what the repository tracks.
+func (s SourceInfo) TrackedRef() Ref {
+	if s.Ref.Type == "" {
+		return NewBranchRef(s.Branch.Short())
+	}
+	return s.Ref
+}
+
+// SetRef makes the repository track [ref].
+func (s *SourceInfo) SetRef(ref Ref) {
+	s.Ref = ref
+	s.Branch = ""
+	if ref.Type == BranchRef {
+		s.Branch = plumbing.NewBranchReferenceName(ref.Name)
+	}
+	s.Tag = ""
 }
 
 // RepoList is a list of repositories that support a single plugin alias.
@@ -27,6 +54,33 @@ type RepoList struct {
 type InstallInfo struct {
 	ID      string           `yaml:"id"`
 	Version version.Semantic `yaml:"version"`
+	// Held vms aren't upgraded.
+	Held bool `yaml:"held,omitempty"`
+	// Constraint restricts which versions the vm is upgraded to. See the
+	// constraint package for the syntax.
+	Constraint string `yaml:"constraint,omitempty"`
+	// Automatic vms were only installed because a joined subnet needed them,
+	// and are uninstalled once no joined subnet does.
+	Automatic bool `yaml:"automatic,omitempty"`
+	// Subnets are the fully qualified names of the joined subnets that need
+	// the vm.
+	Subnets []string `yaml:"subnets,omitempty"`
+	// RPCChainVMProtocol, MinNodeVersion and MaxNodeVersion are what the
+	// installed version needs from the node, if its definition said.
+	RPCChainVMProtocol uint              `yaml:"rpcChainVMProtocol,omitempty"`
+	MinNodeVersion     *version.Semantic `yaml:"minNodeVersion,omitempty"`
+	MaxNodeVersion     *version.Semantic `yaml:"maxNodeVersion,omitempty"`
+}
+
+// SubnetInfo represents a joined subnet.
+type SubnetInfo struct {
+	ID string `yaml:"id"`
+	// VMs are the fully qualified names of the vms the subnet needed when it
+	// was joined.
+	VMs []string `yaml:"vms"`
+	// Configs are the paths of the subnet and chain configs that were
+	// written for the subnet.
+	Configs []string `yaml:"configs,omitempty"`
 }
 
 // Definition stores a plugin definition alongside the plugin-repository's commit
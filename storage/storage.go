@@ -17,10 +17,17 @@ var (
 	subnetPrefix       = []byte("subnet")
 	registryPrefix     = []byte("registry")
 	installedVMsPrefix = []byte("installed_vms")
+	historyPrefix      = []byte("history")
+	autoUpgradePrefix  = []byte("auto_upgrade")
+	joinedSubnetPrefix = []byte("joined_subnets")
+	profilePrefix      = []byte("profile/")
 
 	_ Storage[any] = &Database[any]{}
 )
 
+// DefaultProfile is the profile used when none is chosen.
+const DefaultProfile = "default"
+
 type Storage[V any] interface {
 	Has(key []byte) (bool, error)
 	Put(key []byte, value V) error
@@ -30,6 +37,21 @@ type Storage[V any] interface {
 	// TODO batching
 }
 
+// NewProfile returns the part of [db] that holds the records of [profile]:
+// its installed vms, joined subnets and auto-upgrade state. The default
+// profile keeps them at the top level, where they were before profiles
+// existed. Repositories and history are shared by every profile.
+func NewProfile(db database.Database, profile string) database.Database {
+	if profile == "" || profile == DefaultProfile {
+		return db
+	}
+
+	prefix := make([]byte, 0, len(profilePrefix)+len(profile))
+	prefix = append(prefix, profilePrefix...)
+	prefix = append(prefix, profile...)
+	return prefixdb.New(prefix, db)
+}
+
 func NewSourceInfo(db database.Database) *Database[SourceInfo] {
 	return &Database[SourceInfo]{
 		db: prefixdb.New(sourceInfoPrefix, db),
@@ -60,6 +82,24 @@ func NewInstalledVMs(db database.Database) *Database[InstallInfo] {
 	}
 }
 
+func NewHistory(db database.Database) *Database[Operation] {
+	return &Database[Operation]{
+		db: prefixdb.New(historyPrefix, db),
+	}
+}
+
+func NewAutoUpgrade(db database.Database) *Database[AutoUpgradeState] {
+	return &Database[AutoUpgradeState]{
+		db: prefixdb.New(autoUpgradePrefix, db),
+	}
+}
+
+func NewJoinedSubnets(db database.Database) *Database[SubnetInfo] {
+	return &Database[SubnetInfo]{
+		db: prefixdb.New(joinedSubnetPrefix, db),
+	}
+}
+
 type Database[V any] struct {
 	db database.Database
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/MetalBlockchain/metalgo/database/memdb"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
@@ -193,3 +194,22 @@ func TestDatabase_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestNewProfile(t *testing.T) {
+	db := memdb.New()
+	defaultVMs := NewInstalledVMs(NewProfile(db, DefaultProfile))
+	legacyVMs := NewInstalledVMs(db)
+	testnetVMs := NewInstalledVMs(NewProfile(db, "testnet"))
+
+	assert.NoError(t, defaultVMs.Put([]byte("vm"), InstallInfo{ID: "default"}))
+	assert.NoError(t, testnetVMs.Put([]byte("vm"), InstallInfo{ID: "testnet"}))
+
+	// the default profile reads records from before profiles existed
+	installInfo, err := legacyVMs.Get([]byte("vm"))
+	assert.NoError(t, err)
+	assert.Equal(t, "default", installInfo.ID)
+
+	installInfo, err = testnetVMs.Get([]byte("vm"))
+	assert.NoError(t, err)
+	assert.Equal(t, "testnet", installInfo.ID)
+}
@@ -3,8 +3,6 @@
 
 package types
 
-// import "github.com/MetalBlockchain/metalgo/subnets"
-//
 var _ Definition = &Subnet{}
 
 type Subnet struct {
@@ -14,7 +12,20 @@ type Subnet struct {
 	Description string   `yaml:"description"`
 	Maintainers []string `yaml:"maintainers"`
 	VMs         []string `yaml:"vms"`
-	// Config      subnets.SubnetConfig `yaml:"config,omitempty"`
+	// Config is written to the node's subnet config for this subnet.
+	Config map[string]interface{} `yaml:"config,omitempty"`
+	// Chains are the configs of the subnet's blockchains.
+	Chains []Chain `yaml:"chains,omitempty"`
+}
+
+// Chain is the config of a blockchain in a subnet.
+type Chain struct {
+	ID string `yaml:"id"`
+	// Config is written to the node's chain config for this blockchain.
+	Config map[string]interface{} `yaml:"config,omitempty"`
+	// Upgrade is written to the node's chain upgrade file for this
+	// blockchain.
+	Upgrade map[string]interface{} `yaml:"upgrade,omitempty"`
 }
 
 func (s Subnet) GetID() string {
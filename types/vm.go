@@ -18,6 +18,14 @@ type VM struct {
 	URL           string           `yaml:"url"`
 	SHA256        string           `yaml:"sha256"`
 	Version       version.Semantic `yaml:"version"`
+
+	// RPCChainVMProtocol is the rpcchainvm protocol version the plugin
+	// speaks. The node must speak the same one. It's 0 if it isn't known.
+	RPCChainVMProtocol uint `yaml:"rpcChainVMProtocol,omitempty"`
+	// MinNodeVersion and MaxNodeVersion are the oldest and newest node
+	// versions the plugin supports. Either may be omitted.
+	MinNodeVersion *version.Semantic `yaml:"minNodeVersion,omitempty"`
+	MaxNodeVersion *version.Semantic `yaml:"maxNodeVersion,omitempty"`
 }
 
 func (vm VM) GetID() string {
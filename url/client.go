@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/cavaliergopher/grab/v3"
+
+	"github.com/shubhamdubey02/apm/logging"
 )
 
 var _ Client = &client{}
@@ -16,14 +18,16 @@ type Client interface {
 	Download(url string, path string) error
 }
 
-func NewClient() Client {
+func NewClient(log logging.Logger) Client {
 	return &client{
-		grab.NewClient(),
+		client: grab.NewClient(),
+		log:    log,
 	}
 }
 
 type client struct {
 	client *grab.Client
+	log    logging.Logger
 }
 
 func (h client) Download(url string, path string) error {
@@ -32,9 +36,9 @@ func (h client) Download(url string, path string) error {
 		return err
 	}
 
-	fmt.Printf("Downloading %v...\n", req.URL())
+	h.log.Info("Downloading %v...", req.URL())
 	resp := h.client.Do(req)
-	fmt.Printf("HTTP response %v\n", resp.HTTPResponse.Status)
+	h.log.Debug("HTTP response %v", resp.HTTPResponse.Status)
 
 	// Start progress loop
 	t := time.NewTicker(1 * time.Second)
@@ -44,7 +48,7 @@ Loop:
 	for {
 		select {
 		case <-t.C:
-			fmt.Printf("  transferred %v / %v bytes (%.2f%%)\n",
+			h.log.Progress("  transferred %v / %v bytes (%.2f%%)",
 				resp.BytesComplete(),
 				resp.Size(),
 				100*resp.Progress())
@@ -4,6 +4,7 @@
 package util
 
 import (
+	"net/url"
 	"strings"
 
 	"github.com/shubhamdubey02/apm/constant"
@@ -22,9 +23,39 @@ func ParseAlias(alias string) (organization string, repository string) {
 }
 
 func ValidAlias(alias string) bool {
-	if organization, repository := ParseAlias(alias); organization == "" || repository == "" {
+	parsed := strings.Split(alias, constant.AliasDelimiter)
+
+	return len(parsed) == 2 && parsed[0] != "" && parsed[1] != ""
+}
+
+// QualifiedName returns true if [name] is a fully qualified name, rather than
+// an alias.
+func QualifiedName(name string) bool {
+	parsed := strings.Split(name, constant.QualifiedNameDelimiter)
+	return len(parsed) > 1
+}
+
+// MatchesVM returns true if the fully qualified [name] refers to the vm [vm],
+// which may be either an alias or a fully qualified name.
+func MatchesVM(name string, vm string) bool {
+	if name == vm {
+		return true
+	}
+	if !QualifiedName(name) || QualifiedName(vm) {
 		return false
 	}
 
-	return true
+	_, plugin := ParseQualifiedName(name)
+	return plugin == vm
+}
+
+// RedactURL hides the password in [rawURL], so it can be logged. Urls that
+// can't be parsed or have no user info are returned as they are.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+
+	return u.Redacted()
 }
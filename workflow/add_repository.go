@@ -11,30 +11,34 @@ import (
 	"github.com/shubhamdubey02/apm/storage"
 )
 
-var _ Workflow = AddRepository{}
+var _ Recordable = AddRepository{}
 
 func NewAddRepository(config AddRepositoryConfig) *AddRepository {
 	return &AddRepository{
 		sourcesList: config.SourcesList,
 		alias:       config.Alias,
 		url:         config.URL,
-		branch:      config.Branch,
+		ref:         config.Ref,
 	}
 }
 
 type AddRepositoryConfig struct {
 	SourcesList storage.Storage[storage.SourceInfo]
 	Alias, URL  string
-	Branch      plumbing.ReferenceName
+	Ref         storage.Ref
 }
 
 type AddRepository struct {
 	sourcesList storage.Storage[storage.SourceInfo]
 	alias, url  string
-	branch      plumbing.ReferenceName
+	ref         storage.Ref
 }
 
 func (a AddRepository) Execute() error {
+	if err := a.ref.Verify(); err != nil {
+		return err
+	}
+
 	aliasBytes := []byte(a.alias)
 
 	if ok, err := a.sourcesList.Has(aliasBytes); err != nil {
@@ -46,8 +50,16 @@ func (a AddRepository) Execute() error {
 	unsynced := storage.SourceInfo{
 		Alias:  a.alias,
 		URL:    a.url,
-		Branch: a.branch,
 		Commit: plumbing.ZeroHash, // hasn't been synced yet
 	}
+	unsynced.SetRef(a.ref)
 	return a.sourcesList.Put(aliasBytes, unsynced)
 }
+
+func (a AddRepository) Operation() storage.Operation {
+	return storage.Operation{
+		Type: storage.AddRepositoryOperation,
+		Name: a.alias,
+		Ref:  a.ref.String(),
+	}
+}
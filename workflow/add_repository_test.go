@@ -53,7 +53,8 @@ func TestAddRepositoryExecute(t *testing.T) {
 						storage.SourceInfo{
 							Alias:  "alias",
 							URL:    "url",
-							Branch: "master",
+							Branch: plumbing.NewBranchReferenceName("master"),
+							Ref:    storage.NewBranchRef("master"),
 							Commit: plumbing.ZeroHash,
 						},
 					).
@@ -73,7 +74,8 @@ func TestAddRepositoryExecute(t *testing.T) {
 						storage.SourceInfo{
 							Alias:  "alias",
 							URL:    "url",
-							Branch: "master",
+							Branch: plumbing.NewBranchReferenceName("master"),
+							Ref:    storage.NewBranchRef("master"),
 							Commit: plumbing.ZeroHash,
 						},
 					).
@@ -103,7 +105,7 @@ func TestAddRepositoryExecute(t *testing.T) {
 					SourcesList: sourcesList,
 					Alias:       "alias",
 					URL:         "url",
-					Branch:      "master",
+					Ref:         storage.NewBranchRef("master"),
 				},
 			)
 
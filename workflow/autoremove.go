@@ -0,0 +1,135 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"github.com/spf13/afero"
+
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/util"
+)
+
+var _ Recordable = &Autoremove{}
+
+type AutoremoveConfig struct {
+	Executor Executor
+
+	InstalledVMs  storage.Storage[storage.InstallInfo]
+	JoinedSubnets storage.Storage[storage.SubnetInfo]
+	RepoFactory   storage.RepositoryFactory
+
+	PluginPath string
+	Fs         afero.Fs
+	Log        logging.Logger
+}
+
+func NewAutoremove(config AutoremoveConfig) *Autoremove {
+	return &Autoremove{
+		executor:      config.Executor,
+		installedVMs:  config.InstalledVMs,
+		joinedSubnets: config.JoinedSubnets,
+		repoFactory:   config.RepoFactory,
+		pluginPath:    config.PluginPath,
+		fs:            config.Fs,
+		log:           config.Log,
+	}
+}
+
+// Autoremove uninstalls the vms that were installed for subnets, and aren't
+// needed by any joined subnet anymore.
+type Autoremove struct {
+	executor Executor
+
+	installedVMs  storage.Storage[storage.InstallInfo]
+	joinedSubnets storage.Storage[storage.SubnetInfo]
+	repoFactory   storage.RepositoryFactory
+
+	pluginPath string
+	fs         afero.Fs
+	log        logging.Logger
+}
+
+func (a *Autoremove) Execute() error {
+	orphans, err := OrphanedVMs(a.installedVMs, a.joinedSubnets)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		a.log.Info("No virtual machines to remove.")
+		return nil
+	}
+
+	for _, name := range orphans {
+		alias, plugin := util.ParseQualifiedName(name)
+		uninstall := NewUninstall(UninstallConfig{
+			Name:         name,
+			Plugin:       plugin,
+			RepoAlias:    alias,
+			VMStorage:    a.repoFactory.GetRepository([]byte(alias)).VMs,
+			InstalledVMs: a.installedVMs,
+			Fs:           a.fs,
+			PluginPath:   a.pluginPath,
+			Log:          a.log,
+		})
+		if err := a.executor.Execute(uninstall); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Autoremove) Operation() storage.Operation {
+	return storage.Operation{
+		Type: storage.AutoremoveOperation,
+	}
+}
+
+// OrphanedVMs returns the vms that were installed for subnets, and aren't
+// needed by any joined subnet anymore.
+func OrphanedVMs(
+	installedVMs storage.Storage[storage.InstallInfo],
+	joinedSubnets storage.Storage[storage.SubnetInfo],
+) ([]string, error) {
+	itr := installedVMs.Iterator()
+	defer itr.Release()
+
+	orphans := []string{}
+	for itr.Next() {
+		installInfo, err := itr.Value()
+		if err != nil {
+			return nil, err
+		}
+		if !installInfo.Automatic {
+			continue
+		}
+
+		needed, err := JoinedSubnets(joinedSubnets, installInfo.Subnets)
+		if err != nil {
+			return nil, err
+		}
+		if len(needed) == 0 {
+			orphans = append(orphans, string(itr.Key()))
+		}
+	}
+
+	return orphans, itr.Error()
+}
+
+// JoinedSubnets returns the subnets in [subnets] that are still joined.
+func JoinedSubnets(joinedSubnets storage.Storage[storage.SubnetInfo], subnets []string) ([]string, error) {
+	joined := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		ok, err := joinedSubnets.Has([]byte(subnet))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			joined = append(joined, subnet)
+		}
+	}
+
+	return joined, nil
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database/memdb"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func TestOrphanedVMs(t *testing.T) {
+	db := memdb.New()
+	installedVMs := storage.NewInstalledVMs(db)
+	joinedSubnets := storage.NewJoinedSubnets(db)
+
+	assert.NoError(t, joinedSubnets.Put([]byte("org/repo:joined"), storage.SubnetInfo{}))
+	for name, installInfo := range map[string]storage.InstallInfo{
+		"org/repo:explicit": {},
+		"org/repo:needed":   {Automatic: true, Subnets: []string{"org/repo:left", "org/repo:joined"}},
+		"org/repo:orphan":   {Automatic: true, Subnets: []string{"org/repo:left"}},
+		"org/repo:unneeded": {Automatic: true},
+	} {
+		assert.NoError(t, installedVMs.Put([]byte(name), installInfo))
+	}
+
+	orphans, err := OrphanedVMs(installedVMs, joinedSubnets)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"org/repo:orphan", "org/repo:unneeded"}, orphans)
+
+	joined, err := JoinedSubnets(joinedSubnets, []string{"org/repo:left", "org/repo:joined"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"org/repo:joined"}, joined)
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/types"
+)
+
+// Compatibility is what vms are checked against before they're installed.
+type Compatibility struct {
+	// Node is what the node reported about itself. If it's nil, NodeVersion
+	// is asked instead.
+	Node *admin.NodeVersion
+	// NodeVersion asks the node about itself once a vm needs to be checked.
+	// If it's nil or returns nil, the node couldn't be asked and vms aren't
+	// checked.
+	NodeVersion func() *admin.NodeVersion
+	// Force installs incompatible vms with a warning instead of refusing.
+	Force bool
+}
+
+// Check returns an *IncompatibleError if [vm] can't be installed for the
+// node. Vms are installed anyway if Force is set or the node is unknown,
+// with a warning.
+func (c Compatibility) Check(name string, vm types.VM, log logging.Logger) error {
+	if vm.RPCChainVMProtocol == 0 && vm.MinNodeVersion == nil && vm.MaxNodeVersion == nil {
+		return nil
+	}
+	node := c.node()
+	if node == nil {
+		log.Warn("Couldn't check whether %s is compatible with the node since the node's version is unknown.", name)
+		return nil
+	}
+
+	err := Incompatibilities(name, vm, *node)
+	if err == nil || !c.Force {
+		return err
+	}
+
+	log.Warn("%s. Installing anyway since it was forced.", err)
+	return nil
+}
+
+func (c Compatibility) node() *admin.NodeVersion {
+	if c.Node == nil && c.NodeVersion != nil {
+		return c.NodeVersion()
+	}
+	return c.Node
+}
+
+// Incompatibilities returns an *IncompatibleError describing why [vm] can't
+// run on [node], or nil if it can.
+func Incompatibilities(name string, vm types.VM, node admin.NodeVersion) error {
+	reasons := []string{}
+	// nodes that don't report their protocol can't be checked against it
+	if vm.RPCChainVMProtocol != 0 && node.RPCChainVMProtocol != 0 && vm.RPCChainVMProtocol != node.RPCChainVMProtocol {
+		reasons = append(reasons, fmt.Sprintf("it speaks rpcchainvm protocol %d but the node speaks %d", vm.RPCChainVMProtocol, node.RPCChainVMProtocol))
+	}
+	if vm.MinNodeVersion != nil && node.Version.Compare(vm.MinNodeVersion) < 0 {
+		reasons = append(reasons, fmt.Sprintf("it needs node %s or newer but the node is %s", vm.MinNodeVersion, node.Version))
+	}
+	if vm.MaxNodeVersion != nil && node.Version.Compare(vm.MaxNodeVersion) > 0 {
+		reasons = append(reasons, fmt.Sprintf("it supports node %s or older but the node is %s", vm.MaxNodeVersion, node.Version))
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return &IncompatibleError{
+		Name:    name,
+		Version: &vm.Version,
+		Reasons: reasons,
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/types"
+)
+
+func TestCompatibilityCheck(t *testing.T) {
+	node := &admin.NodeVersion{
+		Version:            &version.Semantic{Major: 1, Minor: 7, Patch: 14},
+		RPCChainVMProtocol: 15,
+	}
+
+	tests := []struct {
+		name          string
+		vm            types.VM
+		compatibility Compatibility
+		wantReasons   []string
+	}{
+		{
+			name:          "no requirements",
+			vm:            types.VM{},
+			compatibility: Compatibility{Node: node},
+		},
+		{
+			name: "compatible",
+			vm: types.VM{
+				RPCChainVMProtocol: 15,
+				MinNodeVersion:     &version.Semantic{Major: 1, Minor: 7, Patch: 14},
+				MaxNodeVersion:     &version.Semantic{Major: 1, Minor: 7, Patch: 99},
+			},
+			compatibility: Compatibility{Node: node},
+		},
+		{
+			name: "protocol mismatch",
+			vm: types.VM{
+				RPCChainVMProtocol: 14,
+			},
+			compatibility: Compatibility{Node: node},
+			wantReasons:   []string{"it speaks rpcchainvm protocol 14 but the node speaks 15"},
+		},
+		{
+			name: "node too old and too new",
+			vm: types.VM{
+				MinNodeVersion: &version.Semantic{Major: 1, Minor: 8, Patch: 0},
+				MaxNodeVersion: &version.Semantic{Major: 1, Minor: 7, Patch: 0},
+			},
+			compatibility: Compatibility{Node: node},
+			wantReasons: []string{
+				"it needs node v1.8.0 or newer but the node is v1.7.14",
+				"it supports node v1.7.0 or older but the node is v1.7.14",
+			},
+		},
+		{
+			name: "node doesn't report its protocol",
+			vm: types.VM{
+				RPCChainVMProtocol: 14,
+			},
+			compatibility: Compatibility{Node: &admin.NodeVersion{Version: node.Version}},
+		},
+		{
+			name: "node unknown",
+			vm: types.VM{
+				RPCChainVMProtocol: 14,
+			},
+		},
+		{
+			name: "node asked lazily",
+			vm: types.VM{
+				RPCChainVMProtocol: 14,
+			},
+			compatibility: Compatibility{NodeVersion: func() *admin.NodeVersion { return node }},
+			wantReasons:   []string{"it speaks rpcchainvm protocol 14 but the node speaks 15"},
+		},
+		{
+			name: "node can't be asked",
+			vm: types.VM{
+				RPCChainVMProtocol: 14,
+			},
+			compatibility: Compatibility{NodeVersion: func() *admin.NodeVersion { return nil }},
+		},
+		{
+			name: "forced",
+			vm: types.VM{
+				RPCChainVMProtocol: 14,
+			},
+			compatibility: Compatibility{Node: node, Force: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.compatibility.Check("organization/repository:vm", test.vm, logging.NoLog{})
+			if len(test.wantReasons) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrIncompatible)
+			incompatible := &IncompatibleError{}
+			assert.ErrorAs(t, err, &incompatible)
+			assert.Equal(t, test.wantReasons, incompatible.Reasons)
+		})
+	}
+}
+
+func TestCompatibilityCheckNoRequirements(t *testing.T) {
+	compatibility := Compatibility{
+		NodeVersion: func() *admin.NodeVersion {
+			t.Fatal("node was asked for a vm without requirements")
+			return nil
+		},
+	}
+
+	assert.NoError(t, compatibility.Check("organization/repository:vm", types.VM{}, logging.NoLog{}))
+}
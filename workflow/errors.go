@@ -0,0 +1,122 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/version"
+)
+
+var (
+	// ErrAlreadyUpdated is returned when upgrading a vm that's already at the
+	// latest version.
+	ErrAlreadyUpdated = errors.New("already up-to-date")
+	// ErrAlreadyInstalled is returned when installing a vm that's already
+	// installed.
+	ErrAlreadyInstalled = errors.New("already installed")
+	// ErrNotInstalled is returned when changing a vm that isn't installed.
+	ErrNotInstalled = errors.New("not installed")
+	// ErrNotJoined is returned when leaving a subnet that wasn't joined.
+	ErrNotJoined = errors.New("not joined")
+	// ErrUnknownVM is returned when a vm isn't defined by its repository.
+	ErrUnknownVM = errors.New("unknown vm")
+	// ErrUnknownSubnet is returned when a subnet isn't defined by its
+	// repository.
+	ErrUnknownSubnet = errors.New("unknown subnet")
+	// ErrChecksumMismatch is returned when a downloaded archive doesn't match
+	// the checksum in its definition. Use errors.As with
+	// *ChecksumMismatchError to get the checksums.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrDownloadFailed is returned when a vm archive couldn't be downloaded.
+	ErrDownloadFailed = errors.New("download failed")
+	// ErrInstallScriptFailed is returned when a vm's install script exits
+	// with an error.
+	ErrInstallScriptFailed = errors.New("install script failed")
+	// ErrVMLoadFailed is returned when the node fails to load a vm the apm
+	// installed.
+	ErrVMLoadFailed = errors.New("vm failed to load")
+	// ErrIncompatible is returned when a vm can't run on the node it would be
+	// installed for. Use errors.As with *IncompatibleError to get why.
+	ErrIncompatible = errors.New("incompatible with the node")
+	// ErrUpgradeSkipped is returned when a vm isn't upgraded or downgraded
+	// to the version its repository defines because it's held, constrained,
+	// too large a change, or incompatible with the node. Use errors.As with
+	// *SkippedError to get why.
+	ErrUpgradeSkipped = errors.New("upgrade skipped")
+	// ErrAdminAPIOffline is returned when the node's admin api refuses the
+	// connection.
+	ErrAdminAPIOffline = errors.New("admin api offline")
+)
+
+// ChecksumMismatchError is returned when a downloaded archive doesn't match
+// the checksum in its definition.
+type ChecksumMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksums did not match. Expected %s but saw %s", e.Expected, e.Actual)
+}
+
+func (e *ChecksumMismatchError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// IncompatibleError is returned when a vm can't run on the node it would be
+// installed for.
+type IncompatibleError struct {
+	Name    string
+	Version *version.Semantic
+	Reasons []string
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("%s %s is incompatible with the node: %s", e.Name, e.Version, strings.Join(e.Reasons, "; "))
+}
+
+func (e *IncompatibleError) Is(target error) bool {
+	return target == ErrIncompatible
+}
+
+// SkippedError is returned when a vm isn't upgraded or downgraded to the
+// version its repository defines.
+type SkippedError struct {
+	Name    string
+	Version *version.Semantic
+	Reason  string
+	// Incompatible is set if the vm was skipped because it can't run on the
+	// node.
+	Incompatible bool
+}
+
+func (e *SkippedError) Error() string {
+	return fmt.Sprintf("%s wasn't changed to %s: %s", e.Name, e.Version, e.Reason)
+}
+
+func (e *SkippedError) Is(target error) bool {
+	return target == ErrUpgradeSkipped || (e.Incompatible && target == ErrIncompatible)
+}
+
+// stepError wraps the underlying error of a failed step so that it matches
+// both the step's sentinel error and the cause.
+type stepError struct {
+	sentinel error
+	err      error
+}
+
+func (e *stepError) Error() string {
+	return fmt.Sprintf("%s: %s", e.sentinel, e.err)
+}
+
+func (e *stepError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *stepError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/MetalBlockchain/metalgo/database"
+
+	"github.com/shubhamdubey02/apm/constraint"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var _ Recordable = &Hold{}
+
+type HoldConfig struct {
+	Name string
+	// Held stops the vm from being upgraded at all.
+	Held bool
+	// Constraint restricts which versions the vm is upgraded to. Empty
+	// removes the constraint.
+	Constraint string
+
+	InstalledVMs storage.Storage[storage.InstallInfo]
+	Log          logging.Logger
+}
+
+func NewHold(config HoldConfig) *Hold {
+	return &Hold{
+		name:         config.Name,
+		held:         config.Held,
+		constraint:   config.Constraint,
+		installedVMs: config.InstalledVMs,
+		log:          config.Log,
+	}
+}
+
+// Hold changes what a vm may be upgraded to.
+type Hold struct {
+	name       string
+	held       bool
+	constraint string
+
+	installedVMs storage.Storage[storage.InstallInfo]
+	log          logging.Logger
+}
+
+func (h *Hold) Execute() error {
+	c, err := constraint.Parse(h.constraint)
+	if err != nil {
+		return err
+	}
+
+	installInfo, err := h.installedVMs.Get([]byte(h.name))
+	if err == database.ErrNotFound {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, h.name)
+	} else if err != nil {
+		return err
+	}
+
+	installInfo.Held = h.held
+	installInfo.Constraint = c.String()
+	if err := h.installedVMs.Put([]byte(h.name), installInfo); err != nil {
+		return err
+	}
+
+	switch {
+	case h.held:
+		h.log.Info("Holding %s at %s.", h.name, &installInfo.Version)
+	case installInfo.Constraint != "":
+		h.log.Info("Constraining upgrades of %s to %s.", h.name, installInfo.Constraint)
+	default:
+		h.log.Info("%s is no longer held.", h.name)
+	}
+
+	return nil
+}
+
+func (h *Hold) Operation() storage.Operation {
+	operation := storage.Operation{
+		Type: storage.HoldOperation,
+		Name: h.name,
+	}
+	if !h.held && h.constraint == "" {
+		operation.Type = storage.UnholdOperation
+	}
+
+	return operation
+}
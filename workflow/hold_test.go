@@ -0,0 +1,244 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/types"
+)
+
+func TestHoldExecute(t *testing.T) {
+	errWrong := fmt.Errorf("something went wrong")
+	nameBytes := []byte("organization/repository:vm")
+
+	installInfo := storage.InstallInfo{
+		ID:      "id",
+		Version: version.Semantic{Major: 1, Minor: 2, Patch: 3},
+	}
+	held := installInfo
+	held.Held = true
+	constrained := installInfo
+	constrained.Constraint = "~1.2"
+
+	tests := []struct {
+		name       string
+		held       bool
+		constraint string
+		setup      func(*storage.MockStorage[storage.InstallInfo])
+		wantType   storage.OperationType
+		wantErr    assert.ErrorAssertionFunc
+	}{
+		{
+			name:       "invalid constraint",
+			constraint: "!1.2",
+			setup:      func(*storage.MockStorage[storage.InstallInfo]) {},
+			wantType:   storage.HoldOperation,
+			wantErr:    assert.Error,
+		},
+		{
+			name: "not installed",
+			held: true,
+			setup: func(installedVMs *storage.MockStorage[storage.InstallInfo]) {
+				installedVMs.EXPECT().Get(nameBytes).Return(storage.InstallInfo{}, database.ErrNotFound)
+			},
+			wantType: storage.HoldOperation,
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrNotInstalled)
+			},
+		},
+		{
+			name: "can't write installed vms",
+			held: true,
+			setup: func(installedVMs *storage.MockStorage[storage.InstallInfo]) {
+				installedVMs.EXPECT().Get(nameBytes).Return(installInfo, nil)
+				installedVMs.EXPECT().Put(nameBytes, held).Return(errWrong)
+			},
+			wantType: storage.HoldOperation,
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, errWrong, err)
+			},
+		},
+		{
+			name: "hold",
+			held: true,
+			setup: func(installedVMs *storage.MockStorage[storage.InstallInfo]) {
+				installedVMs.EXPECT().Get(nameBytes).Return(installInfo, nil)
+				installedVMs.EXPECT().Put(nameBytes, held).Return(nil)
+			},
+			wantType: storage.HoldOperation,
+			wantErr:  assert.NoError,
+		},
+		{
+			name:       "constrain",
+			constraint: "~1.2",
+			setup: func(installedVMs *storage.MockStorage[storage.InstallInfo]) {
+				installedVMs.EXPECT().Get(nameBytes).Return(held, nil)
+				installedVMs.EXPECT().Put(nameBytes, constrained).Return(nil)
+			},
+			wantType: storage.HoldOperation,
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "unhold",
+			setup: func(installedVMs *storage.MockStorage[storage.InstallInfo]) {
+				installedVMs.EXPECT().Get(nameBytes).Return(constrained, nil)
+				installedVMs.EXPECT().Put(nameBytes, installInfo).Return(nil)
+			},
+			wantType: storage.UnholdOperation,
+			wantErr:  assert.NoError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			installedVMs := storage.NewMockStorage[storage.InstallInfo](ctrl)
+			test.setup(installedVMs)
+
+			wf := NewHold(HoldConfig{
+				Name:         string(nameBytes),
+				Held:         test.held,
+				Constraint:   test.constraint,
+				InstalledVMs: installedVMs,
+				Log:          logging.NoLog{},
+			})
+
+			test.wantErr(t, wf.Execute())
+			assert.Equal(t, test.wantType, wf.Operation().Type)
+		})
+	}
+}
+
+func TestUpgradeVMSkip(t *testing.T) {
+	current := version.Semantic{Major: 1, Minor: 2, Patch: 3}
+	newer := version.Semantic{Major: 2, Minor: 0, Patch: 0}
+	vm := types.VM{
+		Version:            version.Semantic{Major: 1, Minor: 3, Patch: 0},
+		RPCChainVMProtocol: 15,
+	}
+	node := &admin.NodeVersion{
+		Version:            &version.Semantic{Major: 1, Minor: 7, Patch: 14},
+		RPCChainVMProtocol: 16,
+	}
+
+	tests := []struct {
+		name          string
+		installInfo   storage.InstallInfo
+		maxLevel      Level
+		compatibility Compatibility
+		want          string
+		// wantIncompatible is whether the vm was skipped for being
+		// incompatible with the node
+		wantIncompatible bool
+	}{
+		{
+			name:        "upgradable",
+			installInfo: storage.InstallInfo{Version: current},
+			want:        "",
+		},
+		{
+			name:        "held",
+			installInfo: storage.InstallInfo{Version: current, Held: true},
+			want:        "it's held",
+		},
+		{
+			name:        "constrained",
+			installInfo: storage.InstallInfo{Version: current, Constraint: "~1.2"},
+			want:        "it's constrained to ~1.2",
+		},
+		{
+			name:        "constraint allows it",
+			installInfo: storage.InstallInfo{Version: current, Constraint: "<2.0.0"},
+			want:        "",
+		},
+		{
+			name:        "level too large",
+			installInfo: storage.InstallInfo{Version: current},
+			maxLevel:    Patch,
+			want:        "it's a minor upgrade and only patch version changes are allowed",
+		},
+		{
+			name:        "level too large for a downgrade",
+			installInfo: storage.InstallInfo{Version: newer},
+			maxLevel:    Patch,
+			want:        "it's a major downgrade and only patch version changes are allowed",
+		},
+		{
+			name:             "incompatible",
+			installInfo:      storage.InstallInfo{Version: current},
+			compatibility:    Compatibility{Node: node},
+			want:             "it speaks rpcchainvm protocol 15 but the node speaks 16",
+			wantIncompatible: true,
+		},
+		{
+			name:          "incompatible but forced",
+			installInfo:   storage.InstallInfo{Version: current},
+			compatibility: Compatibility{Node: node, Force: true},
+			want:          "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := NewUpgradeVM(UpgradeVMConfig{
+				FullVMName:    "organization/repository:vm",
+				MaxLevel:      test.maxLevel,
+				Compatibility: test.compatibility,
+			})
+
+			skipped, err := u.skip(test.installInfo, vm)
+			assert.NoError(t, err)
+			if test.want == "" {
+				assert.Nil(t, skipped)
+				return
+			}
+
+			assert.Equal(t, test.want, skipped.Reason)
+			assert.ErrorIs(t, skipped, ErrUpgradeSkipped)
+			assert.Equal(t, test.wantIncompatible, errors.Is(skipped, ErrIncompatible))
+		})
+	}
+}
+
+func TestUpgradeVMOperation(t *testing.T) {
+	tests := []struct {
+		name       string
+		downgraded bool
+		want       storage.OperationType
+	}{
+		{
+			name: "upgrade",
+			want: storage.UpgradeOperation,
+		},
+		{
+			name:       "downgrade",
+			downgraded: true,
+			want:       storage.DowngradeOperation,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := NewUpgradeVM(UpgradeVMConfig{FullVMName: "organization/repository:vm"})
+			u.downgraded = test.downgraded
+
+			operation := u.Operation()
+			assert.Equal(t, test.want, operation.Type)
+			assert.Equal(t, "organization/repository:vm", operation.Name)
+		})
+	}
+}
@@ -10,15 +10,18 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/MetalBlockchain/metalgo/database"
 	"github.com/MetalBlockchain/metalgo/utils/perms"
+	"github.com/MetalBlockchain/metalgo/version"
 	"github.com/spf13/afero"
 
 	"github.com/shubhamdubey02/apm/checksum"
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/types"
 )
 
-var _ Workflow = &Install{}
+var _ Recordable = &Install{}
 
 type InstallConfig struct {
 	Name         string
@@ -27,56 +30,82 @@ type InstallConfig struct {
 	Repo         string
 	TmpPath      string
 	PluginPath   string
+	// Automatic is true if a subnet needs the vm, rather than the operator.
+	Automatic bool
+	// Compatibility is what the vm is checked against before it's
+	// downloaded.
+	Compatibility Compatibility
 
 	InstalledVMs storage.Storage[storage.InstallInfo]
 	VMStorage    storage.Storage[storage.Definition[types.VM]]
 	Fs           afero.Fs
 	Installer    Installer
+	Log          logging.Logger
 }
 
 func NewInstall(config InstallConfig) *Install {
 	return &Install{
-		name:         config.Name,
-		plugin:       config.Plugin,
-		organization: config.Organization,
-		repo:         config.Repo,
-		tmpPath:      config.TmpPath,
-		pluginPath:   config.PluginPath,
-		installedVMs: config.InstalledVMs,
-		vmStorage:    config.VMStorage,
-		fs:           config.Fs,
-		installer:    config.Installer,
-		checksummer:  checksum.NewSHA256(config.Fs),
+		name:          config.Name,
+		plugin:        config.Plugin,
+		organization:  config.Organization,
+		repo:          config.Repo,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		automatic:     config.Automatic,
+		compatibility: config.Compatibility,
+		installedVMs:  config.InstalledVMs,
+		vmStorage:     config.VMStorage,
+		fs:            config.Fs,
+		installer:     config.Installer,
+		log:           config.Log,
+		checksummer:   checksum.NewSHA256(config.Fs),
 	}
 }
 
 type Install struct {
-	name         string
-	plugin       string
-	organization string
-	repo         string
-	tmpPath      string
-	pluginPath   string
+	name          string
+	plugin        string
+	organization  string
+	repo          string
+	tmpPath       string
+	pluginPath    string
+	automatic     bool
+	compatibility Compatibility
 
 	installedVMs storage.Storage[storage.InstallInfo]
 	vmStorage    storage.Storage[storage.Definition[types.VM]]
 	fs           afero.Fs
 	installer    Installer
+	log          logging.Logger
 	checksummer  checksum.Checksummer
+
+	// what was installed, for the operation history
+	version  *version.Semantic
+	commit   string
+	checksum string
+	path     string
 }
 
-func (i Install) Execute() error {
+func (i *Install) Execute() error {
 	var (
 		definition storage.Definition[types.VM]
 		err        error
 	)
 
 	definition, err = i.vmStorage.Get([]byte(i.plugin))
-	if err != nil {
+	if err == database.ErrNotFound {
+		return fmt.Errorf("%w: %s", ErrUnknownVM, i.name)
+	} else if err != nil {
 		return err
 	}
 
 	vm := definition.Definition
+	i.version = &vm.Version
+	i.commit = definition.Commit.String()
+
+	if err := i.compatibility.Check(i.name, vm, i.log); err != nil {
+		return err
+	}
 
 	archiveFile := fmt.Sprintf("%s.tar.gz", i.plugin)
 	tmpPath := filepath.Join(i.tmpPath, i.organization, i.repo)
@@ -85,20 +114,24 @@ func (i Install) Execute() error {
 
 	if err := i.installer.Download(vm.URL, archiveFilePath); err != nil {
 		// TODO sometimes these aren't cleaned up if we fail before cleanup step
-		return err
+		return &stepError{sentinel: ErrDownloadFailed, err: err}
 	}
 
-	fmt.Printf("Calculating checksums...\n")
+	i.log.Debug("Calculating checksums...")
 	hash := fmt.Sprintf("%x", i.checksummer.Checksum(archiveFilePath))
+	i.checksum = hash
 	if hash != vm.SHA256 {
-		return fmt.Errorf("checksums did not match. Expected %s but saw %s", vm.SHA256, hash)
+		return &ChecksumMismatchError{
+			Expected: vm.SHA256,
+			Actual:   hash,
+		}
 	}
 
-	fmt.Printf("Saw expected checksum value of %s\n", hash)
+	i.log.Debug("Saw expected checksum value of %s", hash)
 
 	// Create the directory we'll store the plugin sources in if it doesn't exist.
 	if _, err := i.fs.Stat(workingDir); errors.Is(err, fs.ErrNotExist) {
-		fmt.Printf("Creating sources directory...\n")
+		i.log.Debug("Creating sources directory...")
 		if err := i.fs.Mkdir(workingDir, perms.ReadWriteExecute); err != nil {
 			return err
 		}
@@ -106,27 +139,28 @@ func (i Install) Execute() error {
 		return err
 	}
 
-	fmt.Printf("Unpacking %s...\n", i.name)
+	i.log.Info("Unpacking %s...", i.name)
 	if err := i.installer.Decompress(archiveFilePath, workingDir); err != nil {
 		return err
 	}
 
 	if vm.InstallScript != "" {
 		args := strings.Split(vm.InstallScript, " ")
-		fmt.Printf("Running install script at %s...\n", vm.InstallScript)
+		i.log.Info("Running install script at %s...", vm.InstallScript)
 		if err := i.installer.Install(workingDir, args...); err != nil {
-			return err
+			return &stepError{sentinel: ErrInstallScriptFailed, err: err}
 		}
 	} else {
-		fmt.Printf("No install script found for %s.\n", i.name)
+		i.log.Debug("No install script found for %s.", i.name)
 	}
 
-	fmt.Printf("Moving binary %s into plugin directory...\n", vm.ID)
-	if err := i.fs.Rename(filepath.Join(workingDir, vm.BinaryPath), filepath.Join(i.pluginPath, vm.ID)); err != nil {
+	i.log.Debug("Moving binary %s into plugin directory...", vm.ID)
+	i.path = filepath.Join(i.pluginPath, vm.ID)
+	if err := i.fs.Rename(filepath.Join(workingDir, vm.BinaryPath), i.path); err != nil {
 		return err
 	}
 
-	fmt.Printf("Cleaning up temporary files...\n")
+	i.log.Debug("Cleaning up temporary files...")
 	if err := i.fs.Remove(filepath.Join(tmpPath, archiveFile)); err != nil {
 		return err
 	}
@@ -135,15 +169,34 @@ func (i Install) Execute() error {
 		return err
 	}
 
-	fmt.Printf("Adding virtual machine %s to installation registry...\n", vm.ID)
-	installInfo := storage.InstallInfo{
-		ID:      vm.ID,
-		Version: vm.Version,
+	i.log.Debug("Adding virtual machine %s to installation registry...", vm.ID)
+	// holds and install reasons survive reinstalls and upgrades
+	installInfo, err := i.installedVMs.Get([]byte(i.name))
+	if err == database.ErrNotFound {
+		installInfo.Automatic = i.automatic
+	} else if err != nil {
+		return err
 	}
+	installInfo.ID = vm.ID
+	installInfo.Version = vm.Version
+	installInfo.RPCChainVMProtocol = vm.RPCChainVMProtocol
+	installInfo.MinNodeVersion = vm.MinNodeVersion
+	installInfo.MaxNodeVersion = vm.MaxNodeVersion
 	if err := i.installedVMs.Put([]byte(i.name), installInfo); err != nil {
 		return err
 	}
 
-	fmt.Printf("Successfully installed %s@v%v.%v.%v in %s\n", i.name, vm.Version.Major, vm.Version.Minor, vm.Version.Patch, filepath.Join(i.pluginPath, vm.ID))
+	i.log.Info("Successfully installed %s@v%v.%v.%v in %s", i.name, vm.Version.Major, vm.Version.Minor, vm.Version.Patch, filepath.Join(i.pluginPath, vm.ID))
 	return nil
 }
+
+func (i *Install) Operation() storage.Operation {
+	return storage.Operation{
+		Type:     storage.InstallOperation,
+		Name:     i.name,
+		Version:  i.version,
+		Commit:   i.commit,
+		Checksum: i.checksum,
+		Path:     i.path,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/MetalBlockchain/metalgo/database"
 	"github.com/MetalBlockchain/metalgo/utils/perms"
 	"github.com/MetalBlockchain/metalgo/version"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/shubhamdubey02/apm/checksum"
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/types"
 )
@@ -90,6 +92,15 @@ func TestInstallExecute(t *testing.T) {
 		setup   func(mocks)
 		wantErr assert.ErrorAssertionFunc
 	}{
+		{
+			name: "vm not in registry",
+			setup: func(mocks mocks) {
+				mocks.vmStorage.EXPECT().Get([]byte("plugin")).Return(storage.Definition[types.VM]{}, database.ErrNotFound)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrUnknownVM)
+			},
+		},
 		{
 			name: "read vm registry fails",
 			setup: func(mocks mocks) {
@@ -106,7 +117,7 @@ func TestInstallExecute(t *testing.T) {
 				mocks.installer.EXPECT().Download(vm.URL, tarPath).Return(errWrong)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
-				return assert.Equal(t, err, errWrong)
+				return assert.ErrorIs(t, err, ErrDownloadFailed) && assert.ErrorIs(t, err, errWrong)
 			},
 		},
 		{
@@ -119,7 +130,7 @@ func TestInstallExecute(t *testing.T) {
 				mocks.checksummer.EXPECT().Checksum(tarPath).Return([]byte("wrong checksum"))
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
-				return assert.Error(t, err)
+				return assert.ErrorIs(t, err, ErrChecksumMismatch)
 			},
 		},
 		{
@@ -150,7 +161,7 @@ func TestInstallExecute(t *testing.T) {
 				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(errWrong)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
-				return assert.Equal(t, err, errWrong)
+				return assert.ErrorIs(t, err, ErrInstallScriptFailed) && assert.ErrorIs(t, err, errWrong)
 			},
 		},
 		{
@@ -165,6 +176,7 @@ func TestInstallExecute(t *testing.T) {
 					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
 				})
 				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.installedVMs.EXPECT().Get([]byte("name")).Return(storage.InstallInfo{}, database.ErrNotFound)
 				mocks.installedVMs.EXPECT().Put([]byte("name"), expectedVMInstallInfo).Return(errWrong)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
@@ -183,12 +195,39 @@ func TestInstallExecute(t *testing.T) {
 					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
 				})
 				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.installedVMs.EXPECT().Get([]byte("name")).Return(storage.InstallInfo{}, database.ErrNotFound)
 				mocks.installedVMs.EXPECT().Put([]byte("name"), expectedVMInstallInfo).Return(nil)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return assert.Nil(t, err)
 			},
 		},
+		{
+			name: "reinstall keeps constraint",
+			setup: func(mocks mocks) {
+				mocks.vmStorage.EXPECT().Get([]byte("plugin")).Return(definition, nil)
+				mocks.installer.EXPECT().Download(vm.URL, tarPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, tarPath, nil, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(hash)
+				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
+				})
+				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.installedVMs.EXPECT().Get([]byte("name")).Return(storage.InstallInfo{
+					ID:         "id",
+					Version:    version.Semantic{Major: 1},
+					Constraint: "~1",
+				}, nil)
+
+				constrained := expectedVMInstallInfo
+				constrained.Constraint = "~1"
+				mocks.installedVMs.EXPECT().Put([]byte("name"), constrained).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+		},
 		{
 			name: "happy case no install script",
 			setup: func(mocks mocks) {
@@ -200,6 +239,7 @@ func TestInstallExecute(t *testing.T) {
 				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
 					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, noInstallScriptVM.BinaryPath), nil, perms.ReadWrite)
 				})
+				mocks.installedVMs.EXPECT().Get([]byte("name")).Return(storage.InstallInfo{}, database.ErrNotFound)
 				mocks.installedVMs.EXPECT().Put([]byte("name"), expectedNoInstallScriptVMInstallInfo).Return(nil)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
@@ -243,6 +283,7 @@ func TestInstallExecute(t *testing.T) {
 					VMStorage:    vmStorage,
 					Fs:           fs,
 					Installer:    installer,
+					Log:          logging.NoLog{},
 				},
 			)
 			wf.checksummer = checksummer
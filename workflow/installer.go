@@ -4,11 +4,11 @@
 package workflow
 
 import (
-	"os"
 	"os/exec"
 
 	"github.com/spf13/afero"
 
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/url"
 )
 
@@ -25,17 +25,20 @@ var _ Installer = &VMInstaller{}
 type VMInstallerConfig struct {
 	Fs        afero.Fs
 	URLClient url.Client
+	Log       logging.Logger
 }
 
 func NewVMInstaller(config VMInstallerConfig) *VMInstaller {
 	return &VMInstaller{
 		fs:     config.Fs,
 		Client: config.URLClient,
+		log:    config.Log,
 	}
 }
 
 type VMInstaller struct {
-	fs afero.Fs
+	fs  afero.Fs
+	log logging.Logger
 	url.Client
 }
 
@@ -46,8 +49,12 @@ func (t VMInstaller) Decompress(source string, dest string) error {
 
 func (t VMInstaller) Install(workingDir string, args ...string) error {
 	cmd := exec.Command(args[0], args[1:]...) // #nosec G204 installation scripts are assumed to be trusted if a user is tracking a plugin repository
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// the script's output is shown as part of our own progress
+	output := logging.NewWriter(t.log, logging.Info)
+	defer output.Flush()
+
+	cmd.Stdout = output
+	cmd.Stderr = output
 	cmd.Dir = workingDir
 
 	return cmd.Run()
@@ -0,0 +1,375 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/spf13/afero"
+
+	"github.com/shubhamdubey02/apm/constant"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/node"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/types"
+	"github.com/shubhamdubey02/apm/util"
+)
+
+var _ Recordable = &JoinSubnet{}
+
+type JoinSubnetConfig struct {
+	Executor Executor
+
+	FullName      string
+	Repository    storage.Repository
+	InstalledVMs  storage.Storage[storage.InstallInfo]
+	JoinedSubnets storage.Storage[storage.SubnetInfo]
+	// Compatibility is what the subnet's vms are checked against before
+	// they're installed.
+	Compatibility Compatibility
+
+	TmpPath    string
+	PluginPath string
+	Installer  Installer
+	Fs         afero.Fs
+	// NodeConfig is where the subnet is tracked. If it's nil, the operator
+	// is told to track the subnet themselves.
+	NodeConfig *node.Config
+	// NodeConfigs is where the subnet's and its chains' configs are written.
+	// If it's nil, they aren't.
+	NodeConfigs *node.Configs
+	Log         logging.Logger
+}
+
+func NewJoinSubnet(config JoinSubnetConfig) *JoinSubnet {
+	return &JoinSubnet{
+		executor:      config.Executor,
+		fullName:      config.FullName,
+		repository:    config.Repository,
+		installedVMs:  config.InstalledVMs,
+		joinedSubnets: config.JoinedSubnets,
+		compatibility: config.Compatibility,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		installer:     config.Installer,
+		fs:            config.Fs,
+		nodeConfig:    config.NodeConfig,
+		nodeConfigs:   config.NodeConfigs,
+		log:           config.Log,
+	}
+}
+
+type JoinSubnet struct {
+	executor Executor
+
+	fullName      string
+	repository    storage.Repository
+	installedVMs  storage.Storage[storage.InstallInfo]
+	joinedSubnets storage.Storage[storage.SubnetInfo]
+	compatibility Compatibility
+
+	tmpPath     string
+	pluginPath  string
+	installer   Installer
+	fs          afero.Fs
+	nodeConfig  *node.Config
+	nodeConfigs *node.Configs
+	log         logging.Logger
+
+	// the definition commit that was joined, for the operation history
+	commit string
+}
+
+func (j *JoinSubnet) Execute() error {
+	alias, _ := util.ParseQualifiedName(j.fullName)
+	organization, repo := util.ParseAlias(alias)
+
+	subnet, subnetID, chainIDs, err := j.definition()
+	if err != nil {
+		return err
+	}
+
+	if err := j.checkCompatibility(alias, subnet.VMs); err != nil {
+		return err
+	}
+
+	// TODO prompt user
+	j.log.Info("Installing virtual machines for subnet %s.", subnet.GetID())
+	vms := make([]string, 0, len(subnet.VMs))
+	for _, vm := range subnet.VMs {
+		name := strings.Join([]string{alias, vm}, constant.QualifiedNameDelimiter)
+		vms = append(vms, name)
+
+		ok, err := j.installedVMs.Has([]byte(name))
+		if err != nil {
+			return err
+		}
+		if ok {
+			j.log.Info("VM %s is already installed. Skipping.", name)
+		} else {
+			installWorkflow := NewInstall(InstallConfig{
+				Name:          name,
+				Plugin:        vm,
+				Organization:  organization,
+				Repo:          repo,
+				TmpPath:       j.tmpPath,
+				PluginPath:    j.pluginPath,
+				Automatic:     true,
+				Compatibility: j.compatibility,
+				InstalledVMs:  j.installedVMs,
+				VMStorage:     j.repository.VMs,
+				Fs:            j.fs,
+				Installer:     j.installer,
+				Log:           j.log,
+			})
+			if err := j.executor.Execute(installWorkflow); err != nil {
+				return err
+			}
+		}
+
+		if err := j.addDependent(name); err != nil {
+			return err
+		}
+	}
+
+	configs, err := j.writeConfigs(subnetID, subnet, chainIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := j.trackSubnet(subnetID); err != nil {
+		return err
+	}
+
+	if err := j.joinedSubnets.Put([]byte(j.fullName), storage.SubnetInfo{
+		ID:      subnetID.String(),
+		VMs:     vms,
+		Configs: configs,
+	}); err != nil {
+		return err
+	}
+
+	j.log.Info("Finished installing virtual machines for subnet %s.", subnet.ID)
+	return nil
+}
+
+// definition returns the subnet's definition and the ids of the subnet and its
+// chains.
+func (j *JoinSubnet) definition() (types.Subnet, ids.ID, []ids.ID, error) {
+	_, plugin := util.ParseQualifiedName(j.fullName)
+
+	definition, err := j.repository.Subnets.Get([]byte(plugin))
+	if err == database.ErrNotFound {
+		return types.Subnet{}, ids.Empty, nil, fmt.Errorf("%w: %s", ErrUnknownSubnet, j.fullName)
+	} else if err != nil {
+		return types.Subnet{}, ids.Empty, nil, err
+	}
+
+	subnet := definition.Definition
+	j.commit = definition.Commit.String()
+
+	subnetID, err := ids.FromString(subnet.GetID())
+	if err != nil {
+		return types.Subnet{}, ids.Empty, nil, fmt.Errorf("invalid id %s for subnet %s: %w", subnet.GetID(), j.fullName, err)
+	}
+	chainIDs := make([]ids.ID, 0, len(subnet.Chains))
+	for _, chain := range subnet.Chains {
+		chainID, err := ids.FromString(chain.ID)
+		if err != nil {
+			return types.Subnet{}, ids.Empty, nil, fmt.Errorf("invalid id %s for a chain of subnet %s: %w", chain.ID, j.fullName, err)
+		}
+		chainIDs = append(chainIDs, chainID)
+	}
+
+	return subnet, subnetID, chainIDs, nil
+}
+
+// checkCompatibility refuses to join the subnet if any of the [vms] it would
+// install can't run on the node, before anything is installed. Unknown vms
+// are reported by Install.
+func (j *JoinSubnet) checkCompatibility(alias string, vms []string) error {
+	if j.compatibility.Force {
+		return nil
+	}
+
+	for _, vm := range vms {
+		name := strings.Join([]string{alias, vm}, constant.QualifiedNameDelimiter)
+		if ok, err := j.installedVMs.Has([]byte(name)); err != nil {
+			return err
+		} else if ok {
+			continue
+		}
+
+		definition, err := j.repository.VMs.Get([]byte(vm))
+		if err == database.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		node := j.compatibility.node()
+		if node == nil {
+			return nil
+		}
+		if err := Incompatibilities(name, definition.Definition, *node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addDependent records that the subnet needs the installed vm [name].
+func (j *JoinSubnet) addDependent(name string) error {
+	installInfo, err := j.installedVMs.Get([]byte(name))
+	if err != nil {
+		return err
+	}
+
+	for _, subnet := range installInfo.Subnets {
+		if subnet == j.fullName {
+			return nil
+		}
+	}
+	installInfo.Subnets = append(installInfo.Subnets, j.fullName)
+
+	return j.installedVMs.Put([]byte(name), installInfo)
+}
+
+// ConfigChanges returns how joining the subnet would change the node's subnet
+// and chain configs. Nothing is written.
+func (j *JoinSubnet) ConfigChanges() ([]node.Change, error) {
+	subnet, subnetID, chainIDs, err := j.definition()
+	if err != nil {
+		return nil, err
+	}
+
+	return j.configChanges(j.configFiles(subnetID, subnet, chainIDs))
+}
+
+// configFiles returns the subnet's config and its chains' configs and
+// upgrade files, if the definition has any and there's a node config
+// directory to write them to.
+func (j *JoinSubnet) configFiles(subnetID ids.ID, subnet types.Subnet, chainIDs []ids.ID) []node.File {
+	if j.nodeConfigs == nil {
+		return nil
+	}
+
+	files := []node.File{}
+	if subnet.Config != nil {
+		files = append(files, j.nodeConfigs.SubnetConfig(subnetID, subnet.Config))
+	}
+	for i, chain := range subnet.Chains {
+		if chain.Config != nil {
+			files = append(files, j.nodeConfigs.ChainConfig(chainIDs[i], chain.Config))
+		}
+		if chain.Upgrade != nil {
+			files = append(files, j.nodeConfigs.ChainUpgrade(chainIDs[i], chain.Upgrade))
+		}
+	}
+
+	return files
+}
+
+func (j *JoinSubnet) configChanges(files []node.File) ([]node.Change, error) {
+	changes := make([]node.Change, 0, len(files))
+	for _, file := range files {
+		change, err := j.nodeConfigs.Diff(file)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// writeConfigs writes the subnet's config and its chains' configs and
+// upgrade files, if the definition has any. It returns the paths of the
+// configs it created or overwrote.
+func (j *JoinSubnet) writeConfigs(subnetID ids.ID, subnet types.Subnet, chainIDs []ids.ID) ([]string, error) {
+	hasConfigs := subnet.Config != nil
+	for _, chain := range subnet.Chains {
+		hasConfigs = hasConfigs || chain.Config != nil || chain.Upgrade != nil
+	}
+	if !hasConfigs {
+		return nil, nil
+	}
+
+	if j.nodeConfigs == nil {
+		j.log.Warn("Subnet %s has configs for the node, but no node config directory was given. They weren't written.", subnetID)
+		return nil, nil
+	}
+
+	files := j.configFiles(subnetID, subnet, chainIDs)
+	changes, err := j.configChanges(files)
+	if err != nil {
+		return nil, err
+	}
+
+	// what's overwritten is shown before anything is written
+	for _, change := range changes {
+		if !change.Created && change.Changed() {
+			j.log.Warn("Overwriting %s. The previous version will be backed up:\n%s", change.Path, change.Diff)
+		}
+	}
+
+	j.log.Info("Writing configs for subnet %s...", subnetID)
+	paths := make([]string, 0, len(files))
+	for i, file := range files {
+		if _, err := j.nodeConfigs.Write(file); err != nil {
+			return nil, err
+		}
+
+		switch change := changes[i]; {
+		case change.Created:
+			j.log.Info("Wrote %s.", change.Path)
+		case change.Changed():
+			j.log.Info("Overwrote %s.", change.Path)
+		default:
+			j.log.Debug("%s is already up-to-date.", change.Path)
+			continue
+		}
+		paths = append(paths, file.Path)
+	}
+
+	return paths, nil
+}
+
+func (j *JoinSubnet) trackSubnet(subnetID ids.ID) error {
+	// the key the subnets are tracked under was renamed in newer nodes
+	var nodeVersion *version.Semantic
+	if info := j.compatibility.node(); info != nil {
+		nodeVersion = info.Version
+	}
+
+	if j.nodeConfig == nil {
+		j.log.Warn("No node config was given. Add %s to %s in your node config and restart the node to track the subnet.", subnetID, node.TrackSubnetsKeyFor(nodeVersion))
+		return nil
+	}
+
+	j.log.Info("Tracking subnet %s in %s...", subnetID, j.nodeConfig.Path())
+	added, err := j.nodeConfig.TrackSubnet(subnetID, nodeVersion)
+	if err != nil {
+		return err
+	}
+	if !added {
+		j.log.Info("Subnet %s is already tracked.", subnetID)
+		return nil
+	}
+
+	j.log.Warn("Restart the node to start tracking subnet %s.", subnetID)
+	return nil
+}
+
+func (j *JoinSubnet) Operation() storage.Operation {
+	return storage.Operation{
+		Type:   storage.JoinSubnetOperation,
+		Name:   j.fullName,
+		Commit: j.commit,
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/spf13/afero"
+
+	"github.com/shubhamdubey02/apm/constant"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/node"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/util"
+)
+
+var _ Recordable = &LeaveSubnet{}
+
+type LeaveSubnetConfig struct {
+	Executor Executor
+
+	FullName      string
+	Repository    storage.Repository
+	InstalledVMs  storage.Storage[storage.InstallInfo]
+	JoinedSubnets storage.Storage[storage.SubnetInfo]
+
+	PluginPath string
+	Fs         afero.Fs
+	// NodeConfig is where the subnet is untracked. If it's nil, the operator
+	// is told to untrack the subnet themselves.
+	NodeConfig *node.Config
+	// NodeConfigs is where the configs written for the subnet are removed
+	// from.
+	NodeConfigs *node.Configs
+	Log         logging.Logger
+}
+
+func NewLeaveSubnet(config LeaveSubnetConfig) *LeaveSubnet {
+	return &LeaveSubnet{
+		executor:      config.Executor,
+		fullName:      config.FullName,
+		repository:    config.Repository,
+		installedVMs:  config.InstalledVMs,
+		joinedSubnets: config.JoinedSubnets,
+		pluginPath:    config.PluginPath,
+		fs:            config.Fs,
+		nodeConfig:    config.NodeConfig,
+		nodeConfigs:   config.NodeConfigs,
+		log:           config.Log,
+	}
+}
+
+// LeaveSubnet stops tracking a joined subnet, and uninstalls the vms that
+// were installed for it and aren't needed anymore.
+type LeaveSubnet struct {
+	executor Executor
+
+	fullName      string
+	repository    storage.Repository
+	installedVMs  storage.Storage[storage.InstallInfo]
+	joinedSubnets storage.Storage[storage.SubnetInfo]
+
+	pluginPath  string
+	fs          afero.Fs
+	nodeConfig  *node.Config
+	nodeConfigs *node.Configs
+	log         logging.Logger
+}
+
+func (l *LeaveSubnet) Execute() error {
+	subnetInfo, err := l.joinedSubnets.Get([]byte(l.fullName))
+	if err == database.ErrNotFound {
+		subnetInfo, err = l.unrecordedSubnet()
+	}
+	if err != nil {
+		return err
+	}
+
+	subnetID, err := ids.FromString(subnetInfo.ID)
+	if err != nil {
+		return fmt.Errorf("invalid id %s for subnet %s: %w", subnetInfo.ID, l.fullName, err)
+	}
+
+	l.log.Info("Removing virtual machines for subnet %s.", subnetID)
+	for _, name := range subnetInfo.VMs {
+		if err := l.removeDependent(name); err != nil {
+			return err
+		}
+	}
+
+	if len(subnetInfo.Configs) > 0 && l.nodeConfigs != nil {
+		l.log.Info("Removing configs for subnet %s...", subnetID)
+		for _, path := range subnetInfo.Configs {
+			restored, err := l.nodeConfigs.Restore(path)
+			if err != nil {
+				return err
+			}
+			if restored {
+				l.log.Info("Restored the version of %s the subnet overwrote.", path)
+			} else {
+				l.log.Debug("Deleted %s.", path)
+			}
+		}
+	}
+
+	if err := l.untrackSubnet(subnetID); err != nil {
+		return err
+	}
+
+	if err := l.joinedSubnets.Delete([]byte(l.fullName)); err != nil {
+		return err
+	}
+
+	l.log.Info("Finished leaving subnet %s.", subnetID)
+	return nil
+}
+
+// unrecordedSubnet describes a subnet that was joined before joined subnets
+// were recorded, from its definition. It's joined if the node tracks it or
+// an installed vm depends on it. Its configs are left alone, since there's no
+// telling whether they were there before it was joined.
+func (l *LeaveSubnet) unrecordedSubnet() (storage.SubnetInfo, error) {
+	notJoined := fmt.Errorf("%w: %s", ErrNotJoined, l.fullName)
+
+	join := NewJoinSubnet(JoinSubnetConfig{
+		FullName:    l.fullName,
+		Repository:  l.repository,
+		NodeConfigs: l.nodeConfigs,
+		Log:         l.log,
+	})
+	subnet, subnetID, _, err := join.definition()
+	if errors.Is(err, ErrUnknownSubnet) {
+		return storage.SubnetInfo{}, notJoined
+	} else if err != nil {
+		return storage.SubnetInfo{}, err
+	}
+
+	alias, _ := util.ParseQualifiedName(l.fullName)
+	subnetInfo := storage.SubnetInfo{
+		ID:  subnetID.String(),
+		VMs: make([]string, 0, len(subnet.VMs)),
+	}
+	for _, vm := range subnet.VMs {
+		subnetInfo.VMs = append(subnetInfo.VMs, strings.Join([]string{alias, vm}, constant.QualifiedNameDelimiter))
+	}
+
+	joined, err := l.tracked(subnetID)
+	if err != nil {
+		return storage.SubnetInfo{}, err
+	}
+	if !joined {
+		joined, err = l.dependedOn(subnetInfo.VMs)
+		if err != nil {
+			return storage.SubnetInfo{}, err
+		}
+	}
+	if !joined {
+		return storage.SubnetInfo{}, notJoined
+	}
+
+	l.log.Debug("Subnet %s was joined before joined subnets were recorded. Leaving it based on its definition.", l.fullName)
+	return subnetInfo, nil
+}
+
+// tracked returns true if the node config tracks [subnetID].
+func (l *LeaveSubnet) tracked(subnetID ids.ID) (bool, error) {
+	if l.nodeConfig == nil {
+		return false, nil
+	}
+
+	subnets, err := l.nodeConfig.TrackedSubnets()
+	if err != nil {
+		return false, err
+	}
+	for _, subnet := range subnets {
+		if subnet == subnetID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// dependedOn returns true if any of the installed [vms] was installed for
+// the subnet.
+func (l *LeaveSubnet) dependedOn(vms []string) (bool, error) {
+	for _, name := range vms {
+		installInfo, err := l.installedVMs.Get([]byte(name))
+		if err == database.ErrNotFound {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		for _, subnet := range installInfo.Subnets {
+			if subnet == l.fullName {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// removeDependent records that the subnet doesn't need the vm [name]
+// anymore, and uninstalls it if nothing else does.
+func (l *LeaveSubnet) removeDependent(name string) error {
+	installInfo, err := l.installedVMs.Get([]byte(name))
+	if err == database.ErrNotFound {
+		l.log.Debug("VM %s is already not installed. Skipping.", name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	subnets := make([]string, 0, len(installInfo.Subnets))
+	for _, subnet := range installInfo.Subnets {
+		if subnet != l.fullName {
+			subnets = append(subnets, subnet)
+		}
+	}
+	installInfo.Subnets = subnets
+	if err := l.installedVMs.Put([]byte(name), installInfo); err != nil {
+		return err
+	}
+
+	switch {
+	case !installInfo.Automatic:
+		l.log.Info("Keeping %s since it was installed explicitly.", name)
+		return nil
+	case len(subnets) > 0:
+		l.log.Info("Keeping %s since it's needed by %s.", name, strings.Join(subnets, ", "))
+		return nil
+	}
+
+	alias, plugin := util.ParseQualifiedName(name)
+	return l.executor.Execute(NewUninstall(UninstallConfig{
+		Name:         name,
+		Plugin:       plugin,
+		RepoAlias:    alias,
+		VMStorage:    l.repository.VMs,
+		InstalledVMs: l.installedVMs,
+		Fs:           l.fs,
+		PluginPath:   l.pluginPath,
+		Log:          l.log,
+	}))
+}
+
+func (l *LeaveSubnet) untrackSubnet(subnetID ids.ID) error {
+	if l.nodeConfig == nil {
+		l.log.Warn("No node config was given. Remove %s from %s (%s on older nodes) in your node config and restart the node to stop tracking the subnet.", subnetID, node.TrackSubnetsKey, node.WhitelistedSubnetsKey)
+		return nil
+	}
+
+	l.log.Info("Untracking subnet %s in %s...", subnetID, l.nodeConfig.Path())
+	removed, err := l.nodeConfig.UntrackSubnet(subnetID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		l.log.Info("Subnet %s is already not tracked.", subnetID)
+		return nil
+	}
+
+	l.log.Warn("Restart the node to stop tracking subnet %s.", subnetID)
+	return nil
+}
+
+func (l *LeaveSubnet) Operation() storage.Operation {
+	return storage.Operation{
+		Type: storage.LeaveSubnetOperation,
+		Name: l.fullName,
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/node"
+	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/types"
+)
+
+func TestLeaveSubnetExecute(t *testing.T) {
+	const (
+		subnetName = "organization/repository:subnet"
+		otherName  = "organization/repository:other"
+		vmName     = "organization/repository:vm"
+		configPath = "/node/config.json"
+		configsDir = "/node/configs"
+		backupDir  = "/apm/config-backups"
+	)
+	subnetID := ids.ID{1}
+	subnetBytes := []byte(subnetName)
+	vmBytes := []byte(vmName)
+	subnetInfo := storage.SubnetInfo{
+		ID:  subnetID.String(),
+		VMs: []string{vmName},
+	}
+	subnetConfigPath := node.NewConfigs(nil, configsDir, backupDir).SubnetConfig(subnetID, nil).Path
+	subnetBackupPath := filepath.Join(backupDir, "subnets", subnetID.String()+".json")
+	subnetConfig := "{\n  \"validatorOnly\": true\n}\n"
+	definition := storage.Definition[types.Subnet]{
+		Definition: types.Subnet{
+			ID:     subnetID.String(),
+			VMs:    []string{"vm"},
+			Config: map[string]interface{}{"validatorOnly": true},
+		},
+	}
+
+	type mocks struct {
+		executor      *MockExecutor
+		installedVMs  *storage.MockStorage[storage.InstallInfo]
+		joinedSubnets *storage.MockStorage[storage.SubnetInfo]
+		subnets       *storage.MockStorage[storage.Definition[types.Subnet]]
+	}
+	tests := []struct {
+		name  string
+		setup func(mocks)
+		// files are the node configs before leaving, and wantFiles the ones
+		// that are left afterwards
+		files     map[string]string
+		wantFiles map[string]string
+		wantErr   error
+	}{
+		{
+			name: "not joined",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(storage.SubnetInfo{}, database.ErrNotFound)
+				mocks.subnets.EXPECT().Get([]byte("subnet")).Return(storage.Definition[types.Subnet]{}, database.ErrNotFound)
+			},
+			wantErr: ErrNotJoined,
+		},
+		{
+			name: "defined but not joined",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(storage.SubnetInfo{}, database.ErrNotFound)
+				mocks.subnets.EXPECT().Get([]byte("subnet")).Return(storage.Definition[types.Subnet]{
+					Definition: types.Subnet{
+						ID:  ids.ID{2}.String(),
+						VMs: []string{"vm"},
+					},
+				}, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{}, nil)
+			},
+			wantErr: ErrNotJoined,
+		},
+		{
+			name: "joined before joins were recorded",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(storage.SubnetInfo{}, database.ErrNotFound)
+				mocks.subnets.EXPECT().Get([]byte("subnet")).Return(definition, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{}, nil)
+				mocks.installedVMs.EXPECT().Put(vmBytes, storage.InstallInfo{
+					Subnets: []string{},
+				}).Return(nil)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+			files:     map[string]string{subnetConfigPath: subnetConfig},
+			wantFiles: map[string]string{subnetConfigPath: subnetConfig},
+		},
+		{
+			name: "config changed since the unrecorded join is kept",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(storage.SubnetInfo{}, database.ErrNotFound)
+				mocks.subnets.EXPECT().Get([]byte("subnet")).Return(definition, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{}, nil)
+				mocks.installedVMs.EXPECT().Put(vmBytes, storage.InstallInfo{
+					Subnets: []string{},
+				}).Return(nil)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+			files:     map[string]string{subnetConfigPath: "{}\n"},
+			wantFiles: map[string]string{subnetConfigPath: "{}\n"},
+		},
+		{
+			name: "overwritten config is restored",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(storage.SubnetInfo{
+					ID:      subnetID.String(),
+					Configs: []string{subnetConfigPath},
+				}, nil)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+			files: map[string]string{
+				subnetConfigPath: subnetConfig,
+				subnetBackupPath: "{}\n",
+			},
+			wantFiles: map[string]string{subnetConfigPath: "{}\n"},
+		},
+		{
+			name: "uninstalls automatic vm",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(subnetInfo, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{
+					Automatic: true,
+					Subnets:   []string{subnetName},
+				}, nil)
+				mocks.installedVMs.EXPECT().Put(vmBytes, storage.InstallInfo{
+					Automatic: true,
+					Subnets:   []string{},
+				}).Return(nil)
+				mocks.executor.EXPECT().Execute(gomock.AssignableToTypeOf(&Uninstall{})).Return(nil)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+		},
+		{
+			name: "keeps vm needed by another subnet",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(subnetInfo, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{
+					Automatic: true,
+					Subnets:   []string{subnetName, otherName},
+				}, nil)
+				mocks.installedVMs.EXPECT().Put(vmBytes, storage.InstallInfo{
+					Automatic: true,
+					Subnets:   []string{otherName},
+				}).Return(nil)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+		},
+		{
+			name: "keeps explicitly installed vm",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(subnetInfo, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{
+					Subnets: []string{subnetName},
+				}, nil)
+				mocks.installedVMs.EXPECT().Put(vmBytes, storage.InstallInfo{
+					Subnets: []string{},
+				}).Return(nil)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+		},
+		{
+			name: "vm already uninstalled",
+			setup: func(mocks mocks) {
+				mocks.joinedSubnets.EXPECT().Get(subnetBytes).Return(subnetInfo, nil)
+				mocks.installedVMs.EXPECT().Get(vmBytes).Return(storage.InstallInfo{}, database.ErrNotFound)
+				mocks.joinedSubnets.EXPECT().Delete(subnetBytes).Return(nil)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			executor := NewMockExecutor(ctrl)
+			installedVMs := storage.NewMockStorage[storage.InstallInfo](ctrl)
+			joinedSubnets := storage.NewMockStorage[storage.SubnetInfo](ctrl)
+			subnets := storage.NewMockStorage[storage.Definition[types.Subnet]](ctrl)
+			test.setup(mocks{
+				executor:      executor,
+				installedVMs:  installedVMs,
+				joinedSubnets: joinedSubnets,
+				subnets:       subnets,
+			})
+
+			fs := afero.NewMemMapFs()
+			config := `{"track-subnets": "` + subnetID.String() + `"}`
+			assert.NoError(t, afero.WriteFile(fs, configPath, []byte(config), 0o600))
+			nodeConfig := node.NewConfig(fs, configPath)
+			for path, content := range test.files {
+				assert.NoError(t, afero.WriteFile(fs, path, []byte(content), 0o600))
+			}
+
+			wf := NewLeaveSubnet(LeaveSubnetConfig{
+				Executor:      executor,
+				FullName:      subnetName,
+				Repository:    storage.Repository{Subnets: subnets},
+				InstalledVMs:  installedVMs,
+				JoinedSubnets: joinedSubnets,
+				Fs:            fs,
+				NodeConfig:    nodeConfig,
+				NodeConfigs:   node.NewConfigs(fs, configsDir, backupDir),
+				Log:           logging.NoLog{},
+			})
+
+			err := wf.Execute()
+			assert.ErrorIs(t, err, test.wantErr)
+			if test.wantErr != nil {
+				return
+			}
+
+			tracked, err := nodeConfig.TrackedSubnets()
+			assert.NoError(t, err)
+			assert.Empty(t, tracked)
+
+			if test.wantFiles == nil {
+				return
+			}
+
+			files := map[string]string{}
+			assert.NoError(t, afero.Walk(fs, configsDir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				content, err := afero.ReadFile(fs, path)
+				files[path] = string(content)
+				return err
+			}))
+			assert.Equal(t, test.wantFiles, files)
+		})
+	}
+}
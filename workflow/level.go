@@ -0,0 +1,53 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/MetalBlockchain/metalgo/version"
+)
+
+// Level is how large a version change is. The zero value allows any change.
+type Level string
+
+const (
+	AnyLevel Level = ""
+	Patch    Level = "patch"
+	Minor    Level = "minor"
+	Major    Level = "major"
+)
+
+var levelRanks = map[Level]int{
+	Patch:    1,
+	Minor:    2,
+	Major:    3,
+	AnyLevel: 4,
+}
+
+func ParseLevel(level string) (Level, error) {
+	switch Level(level) {
+	case Patch, Minor, Major:
+		return Level(level), nil
+	default:
+		return "", fmt.Errorf("unknown level %s (must be one of %s, %s, or %s)", level, Patch, Minor, Major)
+	}
+}
+
+// ChangeLevel returns the level of the change from [from] to [to].
+func ChangeLevel(from *version.Semantic, to *version.Semantic) Level {
+	switch {
+	case from.Major != to.Major:
+		return Major
+	case from.Minor != to.Minor:
+		return Minor
+	default:
+		return Patch
+	}
+}
+
+// Allows returns true if a change of [level] is at most [l].
+func (l Level) Allows(level Level) bool {
+	return levelRanks[level] <= levelRanks[l]
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevel(t *testing.T) {
+	from := &version.Semantic{Major: 1, Minor: 2, Patch: 3}
+
+	tests := []struct {
+		name      string
+		to        *version.Semantic
+		maxLevel  Level
+		wantLevel Level
+		wantAllow bool
+	}{
+		{
+			name:      "patch allowed by minor",
+			to:        &version.Semantic{Major: 1, Minor: 2, Patch: 4},
+			maxLevel:  Minor,
+			wantLevel: Patch,
+			wantAllow: true,
+		},
+		{
+			name:      "minor not allowed by patch",
+			to:        &version.Semantic{Major: 1, Minor: 3, Patch: 0},
+			maxLevel:  Patch,
+			wantLevel: Minor,
+			wantAllow: false,
+		},
+		{
+			name:      "major not allowed by minor",
+			to:        &version.Semantic{Major: 2, Minor: 0, Patch: 0},
+			maxLevel:  Minor,
+			wantLevel: Major,
+			wantAllow: false,
+		},
+		{
+			name:      "major allowed by default",
+			to:        &version.Semantic{Major: 2, Minor: 0, Patch: 0},
+			maxLevel:  AnyLevel,
+			wantLevel: Major,
+			wantAllow: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level := ChangeLevel(from, test.to)
+			assert.Equal(t, test.wantLevel, level)
+			assert.Equal(t, test.wantAllow, test.maxLevel.Allows(level))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var _ Recordable = &LoadVMs{}
+
+type LoadVMsConfig struct {
+	InstalledVMs     storage.Storage[storage.InstallInfo]
+	AdminClient      admin.Client
+	AdminAPIEndpoint string
+	Log              logging.Logger
+}
+
+func NewLoadVMs(config LoadVMsConfig) *LoadVMs {
+	return &LoadVMs{
+		installedVMs:     config.InstalledVMs,
+		adminClient:      config.AdminClient,
+		adminAPIEndpoint: config.AdminAPIEndpoint,
+		log:              config.Log,
+	}
+}
+
+// LoadVMs asks the node to load the vms that were installed since it
+// started. It fails with ErrVMLoadFailed if the node couldn't load a vm the
+// apm installed.
+type LoadVMs struct {
+	installedVMs     storage.Storage[storage.InstallInfo]
+	adminClient      admin.Client
+	adminAPIEndpoint string
+	log              logging.Logger
+
+	result  admin.LoadVMsResult
+	offline bool
+}
+
+func (l *LoadVMs) Execute() error {
+	l.log.Info("Loading virtual machines...")
+	result, err := l.adminClient.LoadVMs()
+	if err := adminError(err); errors.Is(err, ErrAdminAPIOffline) {
+		l.log.Warn("Node at %s was offline. Virtual machines will be available upon node startup.", l.adminAPIEndpoint)
+		l.offline = true
+		return nil
+	} else if err != nil {
+		return err
+	}
+	l.result = result
+
+	for id, aliases := range result.NewVMs {
+		if len(aliases) == 0 {
+			l.log.Info("Loaded %s.", id)
+			continue
+		}
+		l.log.Info("Loaded %s as %s.", id, strings.Join(aliases, ", "))
+	}
+	if len(result.FailedVMs) == 0 {
+		return nil
+	}
+
+	names, err := l.installedNames()
+	if err != nil {
+		return err
+	}
+
+	failures := []string{}
+	for id, reason := range result.FailedVMs {
+		name, ok := names[id.String()]
+		if !ok {
+			l.log.Warn("Node failed to load %s: %s", id, reason)
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s): %s", name, id, reason))
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	sort.Strings(failures)
+	return fmt.Errorf("%w: %s", ErrVMLoadFailed, strings.Join(failures, "; "))
+}
+
+// installedNames returns the names of the installed vms by their ids.
+func (l *LoadVMs) installedNames() (map[string]string, error) {
+	itr := l.installedVMs.Iterator()
+	defer itr.Release()
+
+	names := map[string]string{}
+	for itr.Next() {
+		installInfo, err := itr.Value()
+		if err != nil {
+			return nil, err
+		}
+		names[installInfo.ID] = string(itr.Key())
+	}
+
+	return names, itr.Error()
+}
+
+// Result returns what the node loaded. It's empty if the node was offline.
+func (l *LoadVMs) Result() admin.LoadVMsResult {
+	return l.result
+}
+
+func (l *LoadVMs) Operation() storage.Operation {
+	operation := storage.Operation{
+		Type: storage.LoadVMsOperation,
+	}
+	if l.offline {
+		operation.Outcome = storage.Skipped
+		operation.Reason = "the node was offline"
+	}
+
+	return operation
+}
+
+// adminError wraps a refused connection to the admin api as
+// ErrAdminAPIOffline.
+func adminError(err error) error {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return &stepError{sentinel: ErrAdminAPIOffline, err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database/memdb"
+	"github.com/MetalBlockchain/metalgo/ids"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/admin"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func TestLoadVMsExecute(t *testing.T) {
+	installedID := ids.ID{1}
+	otherID := ids.ID{2}
+	errWrong := fmt.Errorf("something went wrong")
+
+	tests := []struct {
+		name        string
+		result      admin.LoadVMsResult
+		err         error
+		wantErr     error
+		wantOutcome storage.Outcome
+	}{
+		{
+			name: "loaded",
+			result: admin.LoadVMsResult{
+				NewVMs: map[ids.ID][]string{installedID: {"vm"}},
+			},
+		},
+		{
+			name: "installed vm failed",
+			result: admin.LoadVMsResult{
+				FailedVMs: map[ids.ID]string{installedID: "bad plugin"},
+			},
+			wantErr: ErrVMLoadFailed,
+		},
+		{
+			name: "other vm failed",
+			result: admin.LoadVMsResult{
+				FailedVMs: map[ids.ID]string{otherID: "bad plugin"},
+			},
+		},
+		{
+			name:        "node offline",
+			err:         syscall.ECONNREFUSED,
+			wantOutcome: storage.Skipped,
+		},
+		{
+			name:    "admin api fails",
+			err:     errWrong,
+			wantErr: errWrong,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			adminClient := admin.NewMockClient(ctrl)
+			adminClient.EXPECT().LoadVMs().Return(test.result, test.err)
+
+			installedVMs := storage.NewInstalledVMs(memdb.New())
+			assert.NoError(t, installedVMs.Put([]byte("org/repo:vm"), storage.InstallInfo{ID: installedID.String()}))
+
+			wf := NewLoadVMs(LoadVMsConfig{
+				InstalledVMs: installedVMs,
+				AdminClient:  adminClient,
+				Log:          logging.NoLog{},
+			})
+
+			err := wf.Execute()
+			assert.ErrorIs(t, err, test.wantErr)
+			assert.Equal(t, test.wantOutcome, wf.Operation().Outcome)
+			if test.err == nil {
+				assert.Equal(t, test.result, wf.Result())
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/MetalBlockchain/metalgo/database"
+
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var _ Recordable = &MarkManual{}
+
+type MarkManualConfig struct {
+	Name string
+
+	InstalledVMs storage.Storage[storage.InstallInfo]
+	Log          logging.Logger
+}
+
+func NewMarkManual(config MarkManualConfig) *MarkManual {
+	return &MarkManual{
+		name:         config.Name,
+		installedVMs: config.InstalledVMs,
+		log:          config.Log,
+	}
+}
+
+// MarkManual records that the operator wants a vm that was installed for a
+// subnet, so it isn't uninstalled with the subnet.
+type MarkManual struct {
+	name string
+
+	installedVMs storage.Storage[storage.InstallInfo]
+	log          logging.Logger
+}
+
+func (m *MarkManual) Execute() error {
+	installInfo, err := m.installedVMs.Get([]byte(m.name))
+	if err == database.ErrNotFound {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, m.name)
+	} else if err != nil {
+		return err
+	}
+
+	installInfo.Automatic = false
+	if err := m.installedVMs.Put([]byte(m.name), installInfo); err != nil {
+		return err
+	}
+
+	m.log.Info("%s is now marked as explicitly installed.", m.name)
+	return nil
+}
+
+func (m *MarkManual) Operation() storage.Operation {
+	return storage.Operation{
+		Type: storage.MarkManualOperation,
+		Name: m.name,
+	}
+}
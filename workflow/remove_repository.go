@@ -4,55 +4,98 @@
 package workflow
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
+	"github.com/MetalBlockchain/metalgo/database"
 
 	"github.com/shubhamdubey02/apm/constant"
-	"github.com/shubhamdubey02/apm/state"
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
 )
 
-var _ Workflow = RemoveRepository{}
+var _ Recordable = &RemoveRepository{}
 
 func NewRemoveRepository(config RemoveRepositoryConfig) *RemoveRepository {
 	return &RemoveRepository{
-		sourcesList:      config.SourcesList,
-		repositoriesPath: config.RepositoriesPath,
-		alias:            config.Alias,
+		sourcesList: config.SourcesList,
+		repository:  config.Repository,
+		alias:       config.Alias,
+		log:         config.Log,
 	}
 }
 
 type RemoveRepositoryConfig struct {
-	SourcesList      map[string]*state.SourceInfo
-	RepositoriesPath string
-	Alias            string
+	SourcesList storage.Storage[storage.SourceInfo]
+	Repository  storage.Repository
+	Alias       string
+	Log         logging.Logger
 }
 
 type RemoveRepository struct {
-	sourcesList      map[string]*state.SourceInfo
-	repositoriesPath string
-	alias            string
+	sourcesList storage.Storage[storage.SourceInfo]
+	repository  storage.Repository
+	alias       string
+	log         logging.Logger
+
+	// the commit the repository was at, for the operation history
+	previousCommit string
+	skipped        bool
 }
 
-func (r RemoveRepository) Execute() error {
+func (r *RemoveRepository) Execute() error {
 	if r.alias == constant.CoreAlias {
-		fmt.Printf("Can't remove %s (required repository).\n", constant.CoreAlias)
+		r.log.Warn("Can't remove %s (required repository).", constant.CoreAlias)
+		r.skipped = true
 		return nil
 	}
 
-	_, ok := r.sourcesList[r.alias]
+	aliasBytes := []byte(r.alias)
 
-	repoPath := filepath.Join(r.repositoriesPath, r.alias)
-	if err := os.RemoveAll(repoPath); err != nil {
+	sourceInfo, err := r.sourcesList.Get(aliasBytes)
+	if err == database.ErrNotFound {
+		r.log.Info("%s is already not a tracked repository. Skipping...", r.alias)
+		r.skipped = true
+		return nil
+	} else if err != nil {
 		return err
 	}
+	r.previousCommit = sourceInfo.Commit.String()
 
-	if !ok {
-		fmt.Printf("%s is already not a tracked repository. Skipping...\n", r.alias)
-		return nil
+	// delete all the plugin definitions in the repository
+	vmItr := r.repository.VMs.Iterator()
+	defer vmItr.Release()
+
+	for vmItr.Next() {
+		if err := r.repository.VMs.Delete(vmItr.Key()); err != nil {
+			return err
+		}
 	}
 
-	delete(r.sourcesList, r.alias)
-	fmt.Printf("Successfully removed %s\n", r.alias)
+	subnetItr := r.repository.Subnets.Iterator()
+	defer subnetItr.Release()
+
+	for subnetItr.Next() {
+		if err := r.repository.Subnets.Delete(subnetItr.Key()); err != nil {
+			return err
+		}
+	}
+
+	// remove it from our list of tracked repositories
+	if err := r.sourcesList.Delete(aliasBytes); err != nil {
+		return err
+	}
+
+	r.log.Info("Successfully removed %s", r.alias)
 	return nil
 }
+
+func (r *RemoveRepository) Operation() storage.Operation {
+	operation := storage.Operation{
+		Type:           storage.RemoveRepositoryOperation,
+		Name:           r.alias,
+		PreviousCommit: r.previousCommit,
+	}
+	if r.skipped {
+		operation.Outcome = storage.Skipped
+	}
+
+	return operation
+}
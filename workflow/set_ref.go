@@ -0,0 +1,92 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/MetalBlockchain/metalgo/database"
+
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+var _ Recordable = &SetRef{}
+
+func NewSetRef(config SetRefConfig) *SetRef {
+	return &SetRef{
+		sourcesList: config.SourcesList,
+		alias:       config.Alias,
+		ref:         config.Ref,
+		log:         config.Log,
+	}
+}
+
+type SetRefConfig struct {
+	SourcesList storage.Storage[storage.SourceInfo]
+	Alias       string
+	Ref         storage.Ref
+	Log         logging.Logger
+}
+
+// SetRef changes what a tracked repository follows. The repository is
+// synced to it on the next update.
+type SetRef struct {
+	sourcesList storage.Storage[storage.SourceInfo]
+	alias       string
+	ref         storage.Ref
+	log         logging.Logger
+
+	// the commit the repository was at, for the operation history
+	previousCommit string
+	skipped        bool
+}
+
+func (s *SetRef) Execute() error {
+	if err := s.ref.Verify(); err != nil {
+		return err
+	}
+
+	aliasBytes := []byte(s.alias)
+
+	sourceInfo, err := s.sourcesList.Get(aliasBytes)
+	if err == database.ErrNotFound {
+		return fmt.Errorf("%s isn't a tracked repository: %w", s.alias, err)
+	} else if err != nil {
+		return err
+	}
+	if !sourceInfo.Commit.IsZero() {
+		s.previousCommit = sourceInfo.Commit.String()
+	}
+
+	if sourceInfo.TrackedRef() == s.ref {
+		s.log.Info("%s already tracks %s.", s.alias, s.ref)
+		s.skipped = true
+		return nil
+	}
+
+	sourceInfo.SetRef(s.ref)
+	// the commit is kept as the baseline of the next update
+	sourceInfo.RefChanged = true
+	if err := s.sourcesList.Put(aliasBytes, sourceInfo); err != nil {
+		return err
+	}
+
+	s.log.Info("%s now tracks %s, which is synced on the next update.", s.alias, s.ref)
+	return nil
+}
+
+func (s *SetRef) Operation() storage.Operation {
+	operation := storage.Operation{
+		Type:           storage.SetRefOperation,
+		Name:           s.alias,
+		PreviousCommit: s.previousCommit,
+		Ref:            s.ref.String(),
+	}
+	if s.skipped {
+		operation.Outcome = storage.Skipped
+	}
+
+	return operation
+}
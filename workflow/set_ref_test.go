@@ -0,0 +1,118 @@
+// Copyright (C) 2019-2021, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shubhamdubey02/apm/logging"
+	"github.com/shubhamdubey02/apm/storage"
+)
+
+func TestSetRefExecute(t *testing.T) {
+	const alias = "organization/repository"
+
+	var (
+		commit = plumbing.Hash{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+		tag    = storage.NewTagRef("v1.0.0")
+
+		// tracked before refs were recorded
+		branchSourceInfo = storage.SourceInfo{
+			Alias:  alias,
+			URL:    "url",
+			Branch: plumbing.NewBranchReferenceName("main"),
+			Commit: commit,
+		}
+	)
+
+	tests := []struct {
+		name          string
+		ref           storage.Ref
+		setup         func(sourcesList *storage.MockStorage[storage.SourceInfo])
+		wantErr       error
+		wantOperation storage.Operation
+	}{
+		{
+			name:    "invalid ref",
+			ref:     storage.NewCommitRef("abc"),
+			setup:   func(*storage.MockStorage[storage.SourceInfo]) {},
+			wantErr: storage.ErrInvalidRef,
+			wantOperation: storage.Operation{
+				Type: storage.SetRefOperation,
+				Name: alias,
+				Ref:  "commit abc",
+			},
+		},
+		{
+			name: "untracked repository",
+			ref:  tag,
+			setup: func(sourcesList *storage.MockStorage[storage.SourceInfo]) {
+				sourcesList.EXPECT().Get([]byte(alias)).Return(storage.SourceInfo{}, database.ErrNotFound)
+			},
+			wantErr: database.ErrNotFound,
+			wantOperation: storage.Operation{
+				Type: storage.SetRefOperation,
+				Name: alias,
+				Ref:  "tag v1.0.0",
+			},
+		},
+		{
+			name: "already tracked",
+			ref:  storage.NewBranchRef("main"),
+			setup: func(sourcesList *storage.MockStorage[storage.SourceInfo]) {
+				sourcesList.EXPECT().Get([]byte(alias)).Return(branchSourceInfo, nil)
+			},
+			wantOperation: storage.Operation{
+				Type:           storage.SetRefOperation,
+				Name:           alias,
+				PreviousCommit: commit.String(),
+				Ref:            "branch main",
+				Outcome:        storage.Skipped,
+			},
+		},
+		{
+			name: "pin to tag",
+			ref:  tag,
+			setup: func(sourcesList *storage.MockStorage[storage.SourceInfo]) {
+				sourcesList.EXPECT().Get([]byte(alias)).Return(branchSourceInfo, nil)
+				sourcesList.EXPECT().Put([]byte(alias), storage.SourceInfo{
+					Alias:      alias,
+					URL:        "url",
+					Ref:        tag,
+					Commit:     commit,
+					RefChanged: true,
+				}).Return(nil)
+			},
+			wantOperation: storage.Operation{
+				Type:           storage.SetRefOperation,
+				Name:           alias,
+				PreviousCommit: commit.String(),
+				Ref:            "tag v1.0.0",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			sourcesList := storage.NewMockStorage[storage.SourceInfo](ctrl)
+			test.setup(sourcesList)
+
+			wf := NewSetRef(SetRefConfig{
+				SourcesList: sourcesList,
+				Alias:       alias,
+				Ref:         test.ref,
+				Log:         logging.NoLog{},
+			})
+
+			assert.ErrorIs(t, wf.Execute(), test.wantErr)
+			assert.Equal(t, test.wantOperation, wf.Operation())
+		})
+	}
+}
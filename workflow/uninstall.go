@@ -5,18 +5,20 @@ package workflow
 
 import (
 	"errors"
-	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strings"
 
 	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/version"
 	"github.com/spf13/afero"
 
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/types"
 )
 
-var _ Workflow = &Uninstall{}
+var _ Recordable = &Uninstall{}
 
 func NewUninstall(config UninstallConfig) *Uninstall {
 	return &Uninstall{
@@ -27,6 +29,7 @@ func NewUninstall(config UninstallConfig) *Uninstall {
 		installedVMs: config.InstalledVMs,
 		fs:           config.Fs,
 		pluginPath:   config.PluginPath,
+		log:          config.Log,
 	}
 }
 
@@ -38,6 +41,7 @@ type UninstallConfig struct {
 	InstalledVMs storage.Storage[storage.InstallInfo]
 	Fs           afero.Fs
 	PluginPath   string
+	Log          logging.Logger
 }
 
 type Uninstall struct {
@@ -48,17 +52,26 @@ type Uninstall struct {
 	installedVMs storage.Storage[storage.InstallInfo]
 	fs           afero.Fs
 	pluginPath   string
+	log          logging.Logger
+
+	// the version that was uninstalled, for the operation history
+	previousVersion *version.Semantic
+	path            string
+	skipped         bool
 }
 
-func (u Uninstall) Execute() error {
-	ok, err := u.installedVMs.Has([]byte(u.name))
-	if err != nil {
+func (u *Uninstall) Execute() error {
+	installInfo, err := u.installedVMs.Get([]byte(u.name))
+	if err == database.ErrNotFound {
+		u.log.Info("VM %s is already not installed. Skipping.", u.name)
+		u.skipped = true
+		return nil
+	} else if err != nil {
 		return err
 	}
-
-	if !ok {
-		fmt.Printf("VM %s is already not installed. Skipping.\n", u.name)
-		return nil
+	u.previousVersion = &installInfo.Version
+	if len(installInfo.Subnets) > 0 {
+		u.log.Warn("%s is needed by joined subnets %s. Leave them to stop using it.", u.name, strings.Join(installInfo.Subnets, ", "))
 	}
 
 	vm, err := u.vmStorage.Get([]byte(u.plugin))
@@ -67,22 +80,23 @@ func (u Uninstall) Execute() error {
 		// this used to exist and was removed for whatever reason. In that case,
 		// we should still remove it from our installation registry to unblock
 		// the user.
-		fmt.Printf("Virtual machine %s doesn't exist under the repository for %s. Continuing uninstall anyways...\n", u.plugin, u.repoAlias)
+		u.log.Warn("Virtual machine %s doesn't exist under the repository for %s. Continuing uninstall anyways...", u.plugin, u.repoAlias)
 	} else if err != nil {
 		return err
 	}
 
 	vmPath := filepath.Join(u.pluginPath, vm.Definition.GetID())
+	u.path = vmPath
 
 	switch _, err := u.fs.Stat(vmPath); err {
 	case nil:
-		fmt.Printf("Deleting %s...\n", vmPath)
+		u.log.Debug("Deleting %s...", vmPath)
 		if err := u.fs.Remove(vmPath); err != nil {
 			return err
 		}
 	default:
 		if errors.Is(err, fs.ErrNotExist) {
-			fmt.Printf("%s doesn't exist already. Nothing to delete here.\n", vmPath)
+			u.log.Debug("%s doesn't exist already. Nothing to delete here.", vmPath)
 		} else {
 			return err
 		}
@@ -91,7 +105,21 @@ func (u Uninstall) Execute() error {
 	if err := u.installedVMs.Delete([]byte(u.name)); err != nil {
 		return err
 	}
-	fmt.Printf("Successfully uninstalled %s.\n", u.name)
+	u.log.Info("Successfully uninstalled %s.", u.name)
 
 	return nil
 }
+
+func (u *Uninstall) Operation() storage.Operation {
+	operation := storage.Operation{
+		Type:            storage.UninstallOperation,
+		Name:            u.name,
+		PreviousVersion: u.previousVersion,
+		Path:            u.path,
+	}
+	if u.skipped {
+		operation.Outcome = storage.Skipped
+	}
+
+	return operation
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/types"
 )
@@ -39,6 +40,11 @@ func TestUninstallExecute(t *testing.T) {
 		Commit: plumbing.NewHash("foobar commit"),
 	}
 
+	installInfo := storage.InstallInfo{
+		ID:      "id",
+		Version: version.Semantic{Major: 1, Minor: 2, Patch: 3},
+	}
+
 	type mocks struct {
 		vmStorage    *storage.MockStorage[storage.Definition[types.VM]]
 		installedVMs *storage.MockStorage[storage.InstallInfo]
@@ -51,7 +57,7 @@ func TestUninstallExecute(t *testing.T) {
 		{
 			name: "can't read from installed vms",
 			setup: func(mocks mocks) {
-				mocks.installedVMs.EXPECT().Has(nameBytes).Return(false, errWrong)
+				mocks.installedVMs.EXPECT().Get(nameBytes).Return(storage.InstallInfo{}, errWrong)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return assert.Equal(t, err, errWrong)
@@ -60,7 +66,7 @@ func TestUninstallExecute(t *testing.T) {
 		{
 			name: "vm already uninstalled",
 			setup: func(mocks mocks) {
-				mocks.installedVMs.EXPECT().Has(nameBytes).Return(false, nil)
+				mocks.installedVMs.EXPECT().Get(nameBytes).Return(storage.InstallInfo{}, database.ErrNotFound)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return assert.Nil(t, err)
@@ -69,7 +75,7 @@ func TestUninstallExecute(t *testing.T) {
 		{
 			name: "can't read from repository vms",
 			setup: func(mocks mocks) {
-				mocks.installedVMs.EXPECT().Has(nameBytes).Return(true, nil)
+				mocks.installedVMs.EXPECT().Get(nameBytes).Return(installInfo, nil)
 				mocks.vmStorage.EXPECT().Get(pluginBytes).Return(storage.Definition[types.VM]{}, errWrong)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
@@ -79,7 +85,7 @@ func TestUninstallExecute(t *testing.T) {
 		{
 			name: "uninstalling an invalid vm",
 			setup: func(mocks mocks) {
-				mocks.installedVMs.EXPECT().Has(nameBytes).Return(true, nil)
+				mocks.installedVMs.EXPECT().Get(nameBytes).Return(installInfo, nil)
 				mocks.vmStorage.EXPECT().Get(pluginBytes).Return(storage.Definition[types.VM]{}, database.ErrNotFound)
 				mocks.installedVMs.EXPECT().Delete(nameBytes).Return(nil)
 			},
@@ -90,7 +96,7 @@ func TestUninstallExecute(t *testing.T) {
 		{
 			name: "removing from installation registry fails",
 			setup: func(mocks mocks) {
-				mocks.installedVMs.EXPECT().Has(nameBytes).Return(true, nil)
+				mocks.installedVMs.EXPECT().Get(nameBytes).Return(installInfo, nil)
 				mocks.vmStorage.EXPECT().Get(pluginBytes).Return(definition, nil)
 				mocks.installedVMs.EXPECT().Delete(nameBytes).Return(errWrong)
 			},
@@ -101,7 +107,7 @@ func TestUninstallExecute(t *testing.T) {
 		{
 			name: "success",
 			setup: func(mocks mocks) {
-				mocks.installedVMs.EXPECT().Has(nameBytes).Return(true, nil)
+				mocks.installedVMs.EXPECT().Get(nameBytes).Return(installInfo, nil)
 				mocks.vmStorage.EXPECT().Get(pluginBytes).Return(definition, nil)
 				mocks.installedVMs.EXPECT().Delete(nameBytes).Return(nil)
 			},
@@ -134,6 +140,7 @@ func TestUninstallExecute(t *testing.T) {
 					VMStorage:    vmStorage,
 					InstalledVMs: installedVMs,
 					Fs:           afero.NewMemMapFs(),
+					Log:          logging.NoLog{},
 				},
 			)
 
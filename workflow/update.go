@@ -4,19 +4,20 @@
 package workflow
 
 import (
-	"fmt"
 	"path/filepath"
 
 	"github.com/MetalBlockchain/metalgo/database"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/spf13/afero"
 
+	"github.com/shubhamdubey02/apm/config"
 	"github.com/shubhamdubey02/apm/git"
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/util"
 )
 
-var _ Workflow = &Update{}
+var _ Recordable = &Update{}
 
 type UpdateConfig struct {
 	Executor         Executor
@@ -28,10 +29,11 @@ type UpdateConfig struct {
 	PluginPath       string
 	Installer        Installer
 	RepositoriesPath string
-	Auth             http.BasicAuth
+	Credentials      config.Credentials
 	GitFactory       git.Factory
 	RepoFactory      storage.RepositoryFactory
 	Fs               afero.Fs
+	Log              logging.Logger
 }
 
 func NewUpdate(config UpdateConfig) *Update {
@@ -45,10 +47,11 @@ func NewUpdate(config UpdateConfig) *Update {
 		installer:        config.Installer,
 		sourcesList:      config.SourcesList,
 		repositoriesPath: config.RepositoriesPath,
-		auth:             config.Auth,
+		credentials:      config.Credentials,
 		gitFactory:       config.GitFactory,
 		repoFactory:      config.RepoFactory,
 		fs:               config.Fs,
+		log:              config.Log,
 	}
 }
 
@@ -59,13 +62,14 @@ type Update struct {
 	installedVMs     storage.Storage[storage.InstallInfo]
 	sourcesList      storage.Storage[storage.SourceInfo]
 	installer        Installer
-	auth             http.BasicAuth
+	credentials      config.Credentials
 	tmpPath          string
 	pluginPath       string
 	repositoriesPath string
 	gitFactory       git.Factory
 	repoFactory      storage.RepositoryFactory
 	fs               afero.Fs
+	log              logging.Logger
 }
 
 func (u Update) Execute() error {
@@ -83,13 +87,44 @@ func (u Update) Execute() error {
 		}
 		previousCommit := sourceInfo.Commit
 		repositoryPath := filepath.Join(u.repositoriesPath, organization, repo)
-		latestCommit, err := u.gitFactory.GetRepository(sourceInfo.URL, repositoryPath, sourceInfo.Branch, &u.auth)
+		auth, err := git.NewAuth(sourceInfo.URL, u.credentials.Lookup(sourceInfo.URL))
 		if err != nil {
 			return err
 		}
+		// a commit of another ref isn't expected to be in the new ref's
+		// history, so it isn't checked for a rewrite
+		refChanged := sourceInfo.RefChanged
+		sourceInfo.RefChanged = false
+		ancestor := previousCommit
+		if refChanged {
+			ancestor = plumbing.ZeroHash
+		}
+		sync, err := u.gitFactory.GetRepository(sourceInfo.URL, repositoryPath, sourceInfo.TrackedRef(), ancestor, auth)
+		if err != nil {
+			return err
+		}
+		latestCommit := sync.Commit
+		if sync.Recloned {
+			u.log.Warn("The checkout of %s was unusable, so it was cloned again.", alias)
+		}
+		if sync.ForcePushed {
+			u.log.Warn("The history of %s was rewritten: %s is no longer an ancestor of %s.", alias, previousCommit, latestCommit)
+		}
+
+		tagChanged := sync.Tag != "" && sync.Tag != sourceInfo.Tag
+		if tagChanged {
+			u.log.Info("Following %s at tag %s.", alias, sync.Tag)
+			sourceInfo.Tag = sync.Tag
+		}
 
 		if latestCommit == previousCommit {
-			fmt.Printf("Already at latest for %s@%s.\n", alias, latestCommit)
+			// a new tag or ref can point to the commit that's already synced
+			if tagChanged || refChanged {
+				if err := u.sourcesList.Put(aliasBytes, sourceInfo); err != nil {
+					return err
+				}
+			}
+			u.log.Info("Already at latest for %s@%s.", alias, latestCommit)
 			continue
 		}
 
@@ -99,11 +134,13 @@ func (u Update) Execute() error {
 			AliasBytes:     aliasBytes,
 			PreviousCommit: previousCommit,
 			LatestCommit:   latestCommit,
+			ForcePushed:    sync.ForcePushed,
 			Repository:     u.repoFactory.GetRepository(aliasBytes),
 			Registry:       u.registry,
 			SourceInfo:     sourceInfo,
 			SourcesList:    u.sourcesList,
 			Fs:             u.fs,
+			Log:            u.log,
 		})
 
 		if err := u.executor.Execute(workflow); err != nil {
@@ -113,3 +150,9 @@ func (u Update) Execute() error {
 
 	return nil
 }
+
+func (u Update) Operation() storage.Operation {
+	return storage.Operation{
+		Type: storage.UpdateOperation,
+	}
+}
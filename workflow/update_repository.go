@@ -4,7 +4,6 @@
 package workflow
 
 import (
-	"fmt"
 	"path/filepath"
 	"sort"
 
@@ -13,18 +12,20 @@ import (
 	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
 
+	"github.com/shubhamdubey02/apm/constant"
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/types"
 )
 
 var (
-	subnetDir = "subnets"
-	vmDir     = "vms"
+	subnetDir = constant.SubnetsDir
+	vmDir     = constant.VMsDir
 
 	subnetKey = "subnet"
 	vmKey     = "vm"
 
-	_ Workflow = &UpdateRepository{}
+	_ Recordable = &UpdateRepository{}
 )
 
 type UpdateRepositoryConfig struct {
@@ -35,13 +36,16 @@ type UpdateRepositoryConfig struct {
 
 	PreviousCommit plumbing.Hash
 	LatestCommit   plumbing.Hash
+	// ForcePushed is set if PreviousCommit isn't an ancestor of LatestCommit.
+	ForcePushed bool
 
 	SourceInfo  storage.SourceInfo
 	Repository  storage.Repository
 	Registry    storage.Storage[storage.RepoList]
 	SourcesList storage.Storage[storage.SourceInfo]
 
-	Fs afero.Fs
+	Fs  afero.Fs
+	Log logging.Logger
 }
 
 func NewUpdateRepository(config UpdateRepositoryConfig) *UpdateRepository {
@@ -51,11 +55,13 @@ func NewUpdateRepository(config UpdateRepositoryConfig) *UpdateRepository {
 		aliasBytes:         config.AliasBytes,
 		previousCommit:     config.PreviousCommit,
 		latestCommit:       config.LatestCommit,
+		forcePushed:        config.ForcePushed,
 		repository:         config.Repository,
 		registry:           config.Registry,
 		sourcesList:        config.SourcesList,
 		repositoryMetadata: config.SourceInfo,
 		fs:                 config.Fs,
+		log:                config.Log,
 	}
 }
 
@@ -67,6 +73,7 @@ type UpdateRepository struct {
 
 	previousCommit plumbing.Hash
 	latestCommit   plumbing.Hash
+	forcePushed    bool
 
 	repository  storage.Repository
 	registry    storage.Storage[storage.RepoList]
@@ -74,27 +81,24 @@ type UpdateRepository struct {
 
 	repositoryMetadata storage.SourceInfo
 
-	fs afero.Fs
+	fs  afero.Fs
+	log logging.Logger
 }
 
 func (u *UpdateRepository) Execute() error {
 	if err := u.update(); err != nil {
-		fmt.Printf("Unexpected error while updating definitions. %s", err)
+		u.log.Error("Unexpected error while updating definitions. %s", err)
 		return err
 	}
 
 	// checkpoint progress
-	updatedCheckpoint := storage.SourceInfo{
-		Alias:  u.repositoryMetadata.Alias,
-		URL:    u.repositoryMetadata.URL,
-		Commit: u.latestCommit,
-		Branch: u.repositoryMetadata.Branch,
-	}
+	updatedCheckpoint := u.repositoryMetadata
+	updatedCheckpoint.Commit = u.latestCommit
 	if err := u.sourcesList.Put(u.aliasBytes, updatedCheckpoint); err != nil {
 		return err
 	}
 
-	fmt.Printf("Finished update.\n")
+	u.log.Info("Finished update.")
 
 	return nil
 }
@@ -102,27 +106,27 @@ func (u *UpdateRepository) Execute() error {
 func (u *UpdateRepository) update() error {
 	vmsPath := filepath.Join(u.repositoryPath, vmDir)
 
-	if err := loadFromYAML[types.VM](u.fs, vmKey, vmsPath, u.aliasBytes, u.latestCommit, u.registry, u.repository.VMs); err != nil {
+	if err := loadFromYAML[types.VM](u.fs, u.log, vmKey, vmsPath, u.aliasBytes, u.latestCommit, u.registry, u.repository.VMs); err != nil {
 		return err
 	}
 
 	subnetsPath := filepath.Join(u.repositoryPath, subnetDir)
-	if err := loadFromYAML[types.Subnet](u.fs, subnetKey, subnetsPath, u.aliasBytes, u.latestCommit, u.registry, u.repository.Subnets); err != nil {
+	if err := loadFromYAML[types.Subnet](u.fs, u.log, subnetKey, subnetsPath, u.aliasBytes, u.latestCommit, u.registry, u.repository.Subnets); err != nil {
 		return err
 	}
 
 	// Now we need to delete anything that wasn't updated in the latest commit
-	if err := deleteStaleDefinitions[types.VM](u.repository.VMs, u.latestCommit); err != nil {
+	if err := deleteStaleDefinitions[types.VM](u.log, u.repository.VMs, u.latestCommit); err != nil {
 		return err
 	}
-	if err := deleteStaleDefinitions[types.Subnet](u.repository.Subnets, u.latestCommit); err != nil {
+	if err := deleteStaleDefinitions[types.Subnet](u.log, u.repository.Subnets, u.latestCommit); err != nil {
 		return err
 	}
 
 	if u.previousCommit == plumbing.ZeroHash {
-		fmt.Printf("Finished initializing definitions for %s@%s.\n", u.repoName, u.latestCommit)
+		u.log.Info("Finished initializing definitions for %s@%s.", u.repoName, u.latestCommit)
 	} else {
-		fmt.Printf("Finished updating definitions from %s to %s@%s.\n", u.previousCommit, u.repoName, u.latestCommit)
+		u.log.Info("Finished updating definitions from %s to %s@%s.", u.previousCommit, u.repoName, u.latestCommit)
 	}
 
 	return nil
@@ -130,6 +134,7 @@ func (u *UpdateRepository) update() error {
 
 func loadFromYAML[T types.Definition](
 	fs afero.Fs,
+	log logging.Logger,
 	key string,
 	path string,
 	repositoryAlias []byte,
@@ -202,13 +207,13 @@ func loadFromYAML[T types.Definition](
 			return err
 		}
 
-		fmt.Printf("Updated plugin definition in registry for %s:%s@%s.\n", repositoryAlias, alias, commit)
+		log.Debug("Updated plugin definition in registry for %s:%s@%s.", repositoryAlias, alias, commit)
 	}
 
 	return nil
 }
 
-func deleteStaleDefinitions[T types.Definition](db storage.Storage[storage.Definition[T]], latestCommit plumbing.Hash) error {
+func deleteStaleDefinitions[T types.Definition](log logging.Logger, db storage.Storage[storage.Definition[T]], latestCommit plumbing.Hash) error {
 	itr := db.Iterator()
 	defer itr.Release()
 	// TODO batching
@@ -220,7 +225,7 @@ func deleteStaleDefinitions[T types.Definition](db storage.Storage[storage.Defin
 		}
 
 		if definition.Commit != latestCommit {
-			fmt.Printf("Deleting a stale plugin: %s@%s as of %s.\n", definition.Definition.GetAlias(), definition.Commit, latestCommit)
+			log.Info("Deleting a stale plugin: %s@%s as of %s.", definition.Definition.GetAlias(), definition.Commit, latestCommit)
 			if err := db.Delete(itr.Key()); err != nil {
 				return err
 			}
@@ -229,3 +234,18 @@ func deleteStaleDefinitions[T types.Definition](db storage.Storage[storage.Defin
 
 	return nil
 }
+
+func (u *UpdateRepository) Operation() storage.Operation {
+	operation := storage.Operation{
+		Type:        storage.UpdateOperation,
+		Name:        string(u.aliasBytes),
+		Commit:      u.latestCommit.String(),
+		ForcePushed: u.forcePushed,
+	}
+	// a zero previous commit means this was the initial sync
+	if !u.previousCommit.IsZero() {
+		operation.PreviousCommit = u.previousCommit.String()
+	}
+
+	return operation
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	mockdb "github.com/shubhamdubey02/apm/storage/mocks"
 	"github.com/shubhamdubey02/apm/types"
@@ -247,6 +248,7 @@ func TestUpdateRepositoryExecute(t *testing.T) {
 					Registry:       registry,
 					SourcesList:    sourcesList,
 					Fs:             fs,
+					Log:            logging.NoLog{},
 				},
 			)
 
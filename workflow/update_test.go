@@ -15,7 +15,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 
+	"github.com/shubhamdubey02/apm/config"
 	"github.com/shubhamdubey02/apm/git"
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	mockdb "github.com/shubhamdubey02/apm/storage/mocks"
 )
@@ -25,7 +27,7 @@ func TestUpdateExecute(t *testing.T) {
 		organization     = "organization"
 		repo             = "repository"
 		alias            = "organization/repository"
-		url              = "url"
+		url              = "https://github.com/organization/repository"
 		tmpPath          = "tmpPath"
 		pluginPath       = "pluginPath"
 		repositoriesPath = "repositoriesPath"
@@ -39,7 +41,13 @@ func TestUpdateExecute(t *testing.T) {
 		repoInstallPath = filepath.Join(repositoriesPath, organization, repo)
 		repository      = storage.Repository{}
 
-		auth = http.BasicAuth{
+		credentials = config.Credentials{
+			Credential: config.Credential{
+				Username: "username",
+				Password: "password",
+			},
+		}
+		auth = &http.BasicAuth{
 			Username: "username",
 			Password: "password",
 		}
@@ -61,6 +69,13 @@ func TestUpdateExecute(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	refChanged := sourceInfo
+	refChanged.RefChanged = true
+	refChangedBytes, err := yaml.Marshal(refChanged)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	garbageBytes := []byte("garbage")
 
 	type mocks struct {
@@ -73,7 +88,7 @@ func TestUpdateExecute(t *testing.T) {
 		installer    *MockInstaller
 		gitFactory   *git.MockFactory
 		repoFactory  *storage.MockRepositoryFactory
-		auth         http.BasicAuth
+		auth         *http.BasicAuth
 	}
 	tests := []struct {
 		name    string
@@ -116,7 +131,7 @@ func TestUpdateExecute(t *testing.T) {
 					return *storage.NewIterator[storage.SourceInfo](itr)
 				})
 
-				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, branch, &mocks.auth).Return(plumbing.ZeroHash, errWrong)
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), previousCommit, mocks.auth).Return(git.Sync{}, errWrong)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return assert.Equal(t, errWrong, err)
@@ -149,9 +164,10 @@ func TestUpdateExecute(t *testing.T) {
 					SourceInfo:     sourceInfo,
 					SourcesList:    mocks.sourcesList,
 					Fs:             fs,
+					Log:            logging.NoLog{},
 				})
 
-				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, branch, &mocks.auth).Return(latestCommit, nil)
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), previousCommit, mocks.auth).Return(git.Sync{Commit: latestCommit}, nil)
 				mocks.repoFactory.EXPECT().GetRepository([]byte(alias)).Return(repository)
 				mocks.executor.EXPECT().Execute(wf).Return(errWrong)
 			},
@@ -176,7 +192,58 @@ func TestUpdateExecute(t *testing.T) {
 					return *storage.NewIterator[storage.SourceInfo](itr)
 				})
 
-				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, branch, &mocks.auth).Return(previousCommit, nil)
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), previousCommit, mocks.auth).Return(git.Sync{Commit: previousCommit}, nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name: "new tag at the same commit is saved",
+			setup: func(mocks mocks) {
+				// iterator with only one key/value pair
+				mocks.sourcesList.EXPECT().Iterator().DoAndReturn(func() storage.Iterator[storage.SourceInfo] {
+					itr := mockdb.NewMockIterator(mocks.ctrl)
+					defer itr.EXPECT().Release()
+
+					itr.EXPECT().Next().Return(true)
+					itr.EXPECT().Key().Return([]byte(alias))
+
+					itr.EXPECT().Value().Return(sourceInfoBytes)
+					itr.EXPECT().Next().Return(false)
+
+					return *storage.NewIterator[storage.SourceInfo](itr)
+				})
+
+				tagged := sourceInfo
+				tagged.Tag = "v1.1.0"
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), previousCommit, mocks.auth).Return(git.Sync{Commit: previousCommit, Tag: "v1.1.0"}, nil)
+				mocks.sourcesList.EXPECT().Put([]byte(alias), tagged).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name: "changed ref at the same commit is saved",
+			setup: func(mocks mocks) {
+				// iterator with only one key/value pair
+				mocks.sourcesList.EXPECT().Iterator().DoAndReturn(func() storage.Iterator[storage.SourceInfo] {
+					itr := mockdb.NewMockIterator(mocks.ctrl)
+					defer itr.EXPECT().Release()
+
+					itr.EXPECT().Next().Return(true)
+					itr.EXPECT().Key().Return([]byte(alias))
+
+					itr.EXPECT().Value().Return(refChangedBytes)
+					itr.EXPECT().Next().Return(false)
+
+					return *storage.NewIterator[storage.SourceInfo](itr)
+				})
+
+				// the previous commit isn't checked for a rewrite
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), plumbing.ZeroHash, mocks.auth).Return(git.Sync{Commit: previousCommit}, nil)
+				mocks.sourcesList.EXPECT().Put([]byte(alias), sourceInfo).Return(nil)
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return assert.NoError(t, err)
@@ -210,9 +277,50 @@ func TestUpdateExecute(t *testing.T) {
 					SourceInfo:     sourceInfo,
 					SourcesList:    mocks.sourcesList,
 					Fs:             fs,
+					Log:            logging.NoLog{},
+				})
+
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), previousCommit, mocks.auth).Return(git.Sync{Commit: latestCommit}, nil)
+				mocks.repoFactory.EXPECT().GetRepository([]byte(alias)).Return(repository)
+				mocks.executor.EXPECT().Execute(wf).Return(nil)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
+		{
+			name: "success single repository force-pushed",
+			setup: func(mocks mocks) {
+				// iterator with only one key/value pair
+				mocks.sourcesList.EXPECT().Iterator().DoAndReturn(func() storage.Iterator[storage.SourceInfo] {
+					itr := mockdb.NewMockIterator(mocks.ctrl)
+					defer itr.EXPECT().Release()
+
+					itr.EXPECT().Next().Return(true)
+					itr.EXPECT().Key().Return([]byte(alias))
+
+					itr.EXPECT().Value().Return(sourceInfoBytes)
+					itr.EXPECT().Next().Return(false)
+
+					return *storage.NewIterator[storage.SourceInfo](itr)
+				})
+
+				wf := NewUpdateRepository(UpdateRepositoryConfig{
+					RepoName:       repo,
+					RepositoryPath: repoInstallPath,
+					AliasBytes:     []byte(alias),
+					PreviousCommit: previousCommit,
+					LatestCommit:   latestCommit,
+					ForcePushed:    true,
+					Repository:     repository,
+					Registry:       mocks.registry,
+					SourceInfo:     sourceInfo,
+					SourcesList:    mocks.sourcesList,
+					Fs:             fs,
+					Log:            logging.NoLog{},
 				})
 
-				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, branch, &mocks.auth).Return(latestCommit, nil)
+				mocks.gitFactory.EXPECT().GetRepository(url, repoInstallPath, sourceInfo.TrackedRef(), previousCommit, mocks.auth).Return(git.Sync{Commit: latestCommit, ForcePushed: true}, nil)
 				mocks.repoFactory.EXPECT().GetRepository([]byte(alias)).Return(repository)
 				mocks.executor.EXPECT().Execute(wf).Return(nil)
 			},
@@ -264,10 +372,11 @@ func TestUpdateExecute(t *testing.T) {
 					PluginPath:       pluginPath,
 					Installer:        installer,
 					RepositoriesPath: repositoriesPath,
-					Auth:             auth,
+					Credentials:      credentials,
 					GitFactory:       gitFactory,
 					RepoFactory:      repoFactory,
 					Fs:               fs,
+					Log:              logging.NoLog{},
 				},
 			)
 			test.wantErr(t, wf.Execute())
@@ -4,13 +4,17 @@
 package workflow
 
 import (
-	"fmt"
+	"errors"
 
 	"github.com/spf13/afero"
 
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
+	"github.com/shubhamdubey02/apm/util"
 )
 
+var _ Recordable = &Upgrade{}
+
 type UpgradeConfig struct {
 	Executor Executor
 
@@ -19,23 +23,41 @@ type UpgradeConfig struct {
 	SourcesList  storage.Storage[storage.SourceInfo]
 	InstalledVMs storage.Storage[storage.InstallInfo]
 
+	// MaxLevel is the largest version change allowed. Larger upgrades are
+	// skipped.
+	MaxLevel Level
+	// Exclude are the aliases or fully qualified names of vms that aren't
+	// upgraded.
+	Exclude []string
+	// AllowDowngrade makes vms follow their repository's version even if
+	// it's older than the installed one.
+	AllowDowngrade bool
+	// Compatibility is what new versions are checked against.
+	Compatibility Compatibility
+
 	TmpPath    string
 	PluginPath string
 	Installer  Installer
 	Fs         afero.Fs
+	Log        logging.Logger
 }
 
 func NewUpgrade(config UpgradeConfig) *Upgrade {
 	return &Upgrade{
-		executor:     config.Executor,
-		repoFactory:  config.RepoFactory,
-		registry:     config.Registry,
-		installedVMs: config.InstalledVMs,
-		tmpPath:      config.TmpPath,
-		pluginPath:   config.PluginPath,
-		installer:    config.Installer,
-		sourcesList:  config.SourcesList,
-		fs:           config.Fs,
+		executor:       config.Executor,
+		repoFactory:    config.RepoFactory,
+		registry:       config.Registry,
+		installedVMs:   config.InstalledVMs,
+		maxLevel:       config.MaxLevel,
+		exclude:        config.Exclude,
+		allowDowngrade: config.AllowDowngrade,
+		compatibility:  config.Compatibility,
+		tmpPath:        config.TmpPath,
+		pluginPath:     config.PluginPath,
+		installer:      config.Installer,
+		sourcesList:    config.SourcesList,
+		fs:             config.Fs,
+		log:            config.Log,
 	}
 }
 
@@ -48,11 +70,17 @@ type Upgrade struct {
 	installedVMs storage.Storage[storage.InstallInfo]
 	sourcesList  storage.Storage[storage.SourceInfo]
 
+	maxLevel       Level
+	exclude        []string
+	allowDowngrade bool
+	compatibility  Compatibility
+
 	tmpPath    string
 	pluginPath string
 
 	installer Installer
 	fs        afero.Fs
+	log       logging.Logger
 }
 
 func (u *Upgrade) Execute() error {
@@ -62,19 +90,31 @@ func (u *Upgrade) Execute() error {
 	defer itr.Release()
 
 	for itr.Next() {
+		fullVMName := string(itr.Key())
+		if u.excluded(fullVMName) {
+			u.log.Info("Skipping %s since it's excluded from upgrades.", fullVMName)
+			continue
+		}
+
 		wf := NewUpgradeVM(UpgradeVMConfig{
-			Executor:     u.executor,
-			RepoFactory:  u.repoFactory,
-			FullVMName:   string(itr.Key()),
-			InstalledVMs: u.installedVMs,
-			TmpPath:      u.tmpPath,
-			PluginPath:   u.pluginPath,
-			Installer:    u.installer,
-			Fs:           u.fs,
+			Executor:       u.executor,
+			RepoFactory:    u.repoFactory,
+			FullVMName:     fullVMName,
+			InstalledVMs:   u.installedVMs,
+			MaxLevel:       u.maxLevel,
+			AllowDowngrade: u.allowDowngrade,
+			Compatibility:  u.compatibility,
+			TmpPath:        u.tmpPath,
+			PluginPath:     u.pluginPath,
+			Installer:      u.installer,
+			Fs:             u.fs,
+			Log:            u.log,
 		})
 
+		// vms that can't be upgraded were logged, and don't stop the others
+		// from being upgraded
 		err := u.executor.Execute(wf)
-		if err == nil || err == ErrAlreadyUpdated {
+		if err == nil || err == ErrAlreadyUpdated || errors.Is(err, ErrUpgradeSkipped) {
 			upgraded = true
 		} else if err != nil {
 			return err
@@ -82,9 +122,25 @@ func (u *Upgrade) Execute() error {
 	}
 
 	if !upgraded {
-		fmt.Printf("No changes detected.\n")
+		u.log.Info("No changes detected.")
 		return nil
 	}
 
 	return nil
 }
+
+func (u *Upgrade) excluded(fullVMName string) bool {
+	for _, vm := range u.exclude {
+		if util.MatchesVM(fullVMName, vm) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u *Upgrade) Operation() storage.Operation {
+	return storage.Operation{
+		Type: storage.UpgradeOperation,
+	}
+}
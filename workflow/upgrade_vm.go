@@ -6,16 +6,20 @@ package workflow
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/MetalBlockchain/metalgo/database"
+	"github.com/MetalBlockchain/metalgo/version"
 	"github.com/spf13/afero"
 
+	"github.com/shubhamdubey02/apm/constraint"
+	"github.com/shubhamdubey02/apm/logging"
 	"github.com/shubhamdubey02/apm/storage"
 	"github.com/shubhamdubey02/apm/types"
 	"github.com/shubhamdubey02/apm/util"
 )
 
-var ErrAlreadyUpdated = errors.New("already up-to-date")
+var _ Recordable = &UpgradeVM{}
 
 type UpgradeVMConfig struct {
 	Executor Executor
@@ -24,22 +28,37 @@ type UpgradeVMConfig struct {
 	RepoFactory  storage.RepositoryFactory
 	InstalledVMs storage.Storage[storage.InstallInfo]
 
+	// MaxLevel is the largest version change allowed. Larger upgrades are
+	// skipped.
+	MaxLevel Level
+	// AllowDowngrade makes the vm follow its repository's version even if
+	// it's older than the installed one.
+	AllowDowngrade bool
+	// Compatibility is what the new version is checked against. Upgrades to
+	// incompatible versions are skipped unless they're forced.
+	Compatibility Compatibility
+
 	TmpPath    string
 	PluginPath string
 	Installer  Installer
 	Fs         afero.Fs
+	Log        logging.Logger
 }
 
 func NewUpgradeVM(config UpgradeVMConfig) *UpgradeVM {
 	return &UpgradeVM{
-		executor:     config.Executor,
-		fullVMName:   config.FullVMName,
-		repoFactory:  config.RepoFactory,
-		installedVMs: config.InstalledVMs,
-		tmpPath:      config.TmpPath,
-		pluginPath:   config.PluginPath,
-		installer:    config.Installer,
-		fs:           config.Fs,
+		executor:       config.Executor,
+		fullVMName:     config.FullVMName,
+		repoFactory:    config.RepoFactory,
+		installedVMs:   config.InstalledVMs,
+		maxLevel:       config.MaxLevel,
+		allowDowngrade: config.AllowDowngrade,
+		compatibility:  config.Compatibility,
+		tmpPath:        config.TmpPath,
+		pluginPath:     config.PluginPath,
+		installer:      config.Installer,
+		fs:             config.Fs,
+		log:            config.Log,
 	}
 }
 
@@ -49,13 +68,26 @@ type UpgradeVM struct {
 
 	repoFactory storage.RepositoryFactory
 
-	installedVMs storage.Storage[storage.InstallInfo]
+	installedVMs   storage.Storage[storage.InstallInfo]
+	maxLevel       Level
+	allowDowngrade bool
+	compatibility  Compatibility
 
 	tmpPath    string
 	pluginPath string
 
 	installer Installer
 	fs        afero.Fs
+	log       logging.Logger
+
+	// what was upgraded, for the operation history
+	previousVersion *version.Semantic
+	version         *version.Semantic
+	commit          string
+	checksum        string
+	outcome         storage.Outcome
+	reason          string
+	downgraded      bool
 }
 
 func (u *UpgradeVM) Execute() error {
@@ -72,7 +104,9 @@ func (u *UpgradeVM) Execute() error {
 	repository := u.repoFactory.GetRepository([]byte(repoAlias))
 	definition, err = repository.VMs.Get([]byte(vmName))
 	if err == database.ErrNotFound {
-		fmt.Printf("Warning - found a vm while upgrading %s which is no longer registered in a repository. You should uninstall this VM to avoid noisy logs. Skipping...\n", u.fullVMName)
+		u.log.Warn("Found a vm while upgrading %s which is no longer registered in a repository. You should uninstall this VM to avoid noisy logs. Skipping...", u.fullVMName)
+		u.reason = "it's no longer registered in a repository"
+		u.outcome = storage.Skipped
 		return nil
 	}
 	if err != nil {
@@ -80,10 +114,50 @@ func (u *UpgradeVM) Execute() error {
 	}
 
 	upgradedVM := definition.Definition
+	u.previousVersion = &installInfo.Version
 
-	if installInfo.Version.Compare(&upgradedVM.Version) < 0 {
-		fmt.Printf(
-			"Detected an upgrade for %s from v%v.%v.%v to v%v.%v.%v.\n",
+	comparison := installInfo.Version.Compare(&upgradedVM.Version)
+	if comparison > 0 && !u.allowDowngrade {
+		u.log.Debug(
+			"%s %s is newer than %s in its repository. Not downgrading.",
+			u.fullVMName,
+			&installInfo.Version,
+			&upgradedVM.Version,
+		)
+	}
+
+	if comparison < 0 || (comparison > 0 && u.allowDowngrade) {
+		u.downgraded = comparison > 0
+		change, changing := "an upgrade", "upgrading"
+		if u.downgraded {
+			change, changing = "a downgrade", "downgrading"
+		}
+
+		skipped, err := u.skip(installInfo, upgradedVM)
+		if err != nil {
+			return err
+		}
+		if skipped != nil {
+			u.log.Info(
+				"Not %s %s from %s to %s: %s.",
+				changing,
+				u.fullVMName,
+				&installInfo.Version,
+				&upgradedVM.Version,
+				skipped.Reason,
+			)
+			u.reason = skipped.Reason
+			u.outcome = storage.Skipped
+			return skipped
+		}
+
+		u.version = &upgradedVM.Version
+		u.commit = definition.Commit.String()
+		u.checksum = upgradedVM.SHA256
+
+		u.log.Info(
+			"Detected %s for %s from v%v.%v.%v to v%v.%v.%v.",
+			change,
 			u.fullVMName,
 			installInfo.Version.Major,
 			installInfo.Version.Minor,
@@ -93,20 +167,22 @@ func (u *UpgradeVM) Execute() error {
 			upgradedVM.Version.Patch,
 		)
 		installWorkflow := NewInstall(InstallConfig{
-			Name:         u.fullVMName,
-			Plugin:       vmName,
-			Organization: organization,
-			Repo:         repo,
-			TmpPath:      u.tmpPath,
-			PluginPath:   u.pluginPath,
-			InstalledVMs: u.installedVMs,
-			VMStorage:    repository.VMs,
-			Installer:    u.installer,
-			Fs:           u.fs,
+			Name:          u.fullVMName,
+			Plugin:        vmName,
+			Organization:  organization,
+			Repo:          repo,
+			Compatibility: u.compatibility,
+			TmpPath:       u.tmpPath,
+			PluginPath:    u.pluginPath,
+			InstalledVMs:  u.installedVMs,
+			VMStorage:     repository.VMs,
+			Installer:     u.installer,
+			Fs:            u.fs,
+			Log:           u.log,
 		})
 
-		fmt.Printf(
-			"Rebuilding binaries for %s v%v.%v.%v.\n",
+		u.log.Info(
+			"Rebuilding binaries for %s v%v.%v.%v.",
 			u.fullVMName,
 			upgradedVM.Version.Major,
 			upgradedVM.Version.Minor,
@@ -115,7 +191,77 @@ func (u *UpgradeVM) Execute() error {
 		if err := u.executor.Execute(installWorkflow); err != nil {
 			return err
 		}
+		u.outcome = storage.Succeeded
+	} else {
+		u.outcome = storage.Skipped
 	}
 
 	return ErrAlreadyUpdated
 }
+
+// skip returns why the vm can't be upgraded to [vm], or nil if it can.
+func (u *UpgradeVM) skip(installInfo storage.InstallInfo, vm types.VM) (*SkippedError, error) {
+	to := &vm.Version
+	skipped := &SkippedError{
+		Name:    u.fullVMName,
+		Version: to,
+	}
+	if installInfo.Held {
+		skipped.Reason = "it's held"
+		return skipped, nil
+	}
+
+	if installInfo.Constraint != "" {
+		c, err := constraint.Parse(installInfo.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		if !c.Allows(to) {
+			skipped.Reason = fmt.Sprintf("it's constrained to %s", c)
+			return skipped, nil
+		}
+	}
+
+	if level := ChangeLevel(&installInfo.Version, to); !u.maxLevel.Allows(level) {
+		change := "upgrade"
+		if to.Compare(&installInfo.Version) < 0 {
+			change = "downgrade"
+		}
+		skipped.Reason = fmt.Sprintf("it's a %s %s and only %s version changes are allowed", level, change, u.maxLevel)
+		return skipped, nil
+	}
+
+	if u.compatibility.Force {
+		return nil, nil
+	}
+	if node := u.compatibility.node(); node != nil {
+		var incompatible *IncompatibleError
+		if err := Incompatibilities(u.fullVMName, vm, *node); errors.As(err, &incompatible) {
+			skipped.Reason = strings.Join(incompatible.Reasons, "; ")
+			skipped.Incompatible = true
+			return skipped, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (u *UpgradeVM) Operation() storage.Operation {
+	operationType := storage.UpgradeOperation
+	if u.downgraded {
+		operationType = storage.DowngradeOperation
+	}
+
+	return storage.Operation{
+		Type:            operationType,
+		Name:            u.fullVMName,
+		PreviousVersion: u.previousVersion,
+		Version:         u.version,
+		Commit:          u.commit,
+		Checksum:        u.checksum,
+		Reason:          u.reason,
+		// ErrAlreadyUpdated is returned even when the upgrade went through,
+		// so the outcome can't be inferred from the error alone.
+		Outcome: u.outcome,
+	}
+}
@@ -3,6 +3,18 @@
 
 package workflow
 
+import "github.com/shubhamdubey02/apm/storage"
+
 type Workflow interface {
 	Execute() error
 }
+
+// Recordable is implemented by workflows that should show up in the operation
+// history.
+type Recordable interface {
+	Workflow
+	// Operation returns the details of what the workflow did. It is called by
+	// the executor after Execute returns. The executor fills in the id, user,
+	// timestamps, and the outcome if it was left empty.
+	Operation() storage.Operation
+}